@@ -4,9 +4,26 @@
 
 package backend
 
+import (
+	"time"
+)
+
 // Secret represents a generic secret with lifecycle and data retrieval methods.
 type Secret interface {
 	Close()
 
 	GetData(noCache bool) (*SecretData, error)
+
+	// Status returns the state of the secret cache.
+	Status() SecretStatus
+}
+
+// SecretStatus is the state of a secret cache, nil values being unknown.
+type SecretStatus struct {
+	FetchedAt      *time.Time // last time the data were received
+	ExpiresAt      *time.Time // the data are requested again after this time
+	LeaseExpiresAt *time.Time // the data (eg. credentials, certificate) are no longer valid after this time
+	Version        *string    // version of the data (eg. K/V v2 secret version)
+	LastError      error      // error of the last request, nil when it succeeded
+	LastErrorAt    *time.Time
 }
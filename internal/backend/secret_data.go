@@ -16,4 +16,24 @@ type SecretData interface {
 
 	GetKeys() []string
 	GetValue(key string) (*string, bool)
+
+	// Metadata returns the metadata of the secret (eg. version or lease),
+	// kept apart from its fields, by dotfile path (eg. ".lease-id" or
+	// "app/.metadata-owner").
+	Metadata() map[string]string
+
+	// FieldTransforms returns the transforms declared by the secret itself,
+	// by field name.
+	FieldTransforms() map[string][]string
+
+	// FieldAttrs returns the owner, group and mode declared by the secret
+	// itself, by field name.
+	FieldAttrs() map[string]FieldAttr
+}
+
+// FieldAttr overrides the owner, group and mode of a secret field file.
+type FieldAttr struct {
+	UId  *uint16
+	GId  *uint16
+	Mode *uint32
 }
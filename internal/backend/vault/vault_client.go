@@ -9,6 +9,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
+	"strconv"
 	"sync"
 
 	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
@@ -38,14 +40,20 @@ type VaultClient struct {
 }
 
 type VaultClientConfig struct {
-	optClientHttp options.OptClientHttp
-	optVaultAuth  options.OptVaultAuth
+	optClientHttp   options.OptClientHttp
+	optVaultAuth    options.OptVaultAuth
+	engineNamespace *string
 }
 
 func (z *VaultClientConfig) cacheId_() string {
 	r := ""
 	r += z.optClientHttp.CacheId_()
 	r += z.optVaultAuth.CacheId_()
+	if z.engineNamespace == nil {
+		r += "nil"
+	} else {
+		r += strconv.Quote(*z.engineNamespace)
+	}
 	return r
 }
 
@@ -153,6 +161,11 @@ func (z *VaultClient) createApiUnsafe(clientCertFile *string, clientKeyFile *str
 	apiClient.ClearToken()
 	apiClient.ClearNamespace()
 
+	// login happens in the auth namespace, see login()
+	if z.config.optVaultAuth.Namespace != nil {
+		apiClient.SetNamespace(*z.config.optVaultAuth.Namespace)
+	}
+
 	z.client = apiClient
 	return nil
 }
@@ -302,8 +315,17 @@ func (z *VaultClient) login() error {
 		return fmt.Errorf("internal error")
 	}
 
+	// the auth token is renewed in the auth namespace while the secrets are
+	// read from the engine namespace
+	authClient := z.client
+	if z.config.engineNamespace == nil {
+		z.client = authClient.WithNamespace("")
+	} else {
+		z.client = authClient.WithNamespace(*z.config.engineNamespace)
+	}
+
 	if authSecret != nil && authSecret.Auth.Renewable {
-		lifetimeWatcherId, err := z.NewLifetimeWatcher(vaultApi.LifetimeWatcherInput{
+		lifetimeWatcherId, err := z.newLifetimeWatcher(authClient, vaultApi.LifetimeWatcherInput{
 			Secret:    authSecret,
 			Increment: z.config.optVaultAuth.TokenRenewTtl,
 		}, func(err error) {
@@ -342,9 +364,13 @@ func (z *VaultClient) logout() {
 }
 
 func (z *VaultClient) NewLifetimeWatcher(input vaultApi.LifetimeWatcherInput, onDone func(error), onRenewed func(*vaultApi.RenewOutput)) (*string, error) {
+	return z.newLifetimeWatcher(z.client, input, onDone, onRenewed)
+}
+
+func (z *VaultClient) newLifetimeWatcher(client *vaultApi.Client, input vaultApi.LifetimeWatcherInput, onDone func(error), onRenewed func(*vaultApi.RenewOutput)) (*string, error) {
 	lifetimeWatcherId := uuid.New().String()
 
-	lifetimeWatcher, err := z.client.NewLifetimeWatcher(&input)
+	lifetimeWatcher, err := client.NewLifetimeWatcher(&input)
 	if err != nil {
 		return nil, err
 	}
@@ -443,3 +469,199 @@ func (z *VaultClient) FetchKVv2Secret(engineMountPath string, secretPath string,
 
 	return vaultKvSecret, nil
 }
+
+// ListKVSecrets lists the keys under a KV engine path, the subpaths keys
+// ending with a slash.
+func (z *VaultClient) ListKVSecrets(engineMountPath string, engineVersion int, secretPath string) ([]string, error) {
+	util.Tracef("VaultClient[%v].ListKVSecrets(%s, %d, %s)\n", z, engineMountPath, engineVersion, secretPath)
+
+	if err := z.login(); err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+
+	listPath := path.Join(engineMountPath, secretPath)
+	if engineVersion == 2 {
+		listPath = path.Join(engineMountPath, "metadata", secretPath)
+	}
+
+	secret, err := z.client.Logical().ListWithContext(context.Background(), listPath)
+	if err != nil {
+		// until we got a better way of detecting it, just logout on error (auth token might be expired)
+		z.logout()
+
+		return nil, err
+	}
+
+	if secret == nil {
+		return nil, os.ErrNotExist
+	}
+
+	keys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, errors.New("KV list response is missing the keys field")
+	}
+
+	r := make([]string, 0, len(keys))
+	for _, k := range keys {
+		s, ok := k.(string)
+		if !ok {
+			return nil, errors.New("KV list response keys field is not a list of strings")
+		}
+
+		r = append(r, s)
+	}
+
+	return r, nil
+}
+
+func (z *VaultClient) FetchDbCredentials(engineMountPath string, roleName string) (*vaultApi.Secret, error) {
+	util.Tracef("VaultClient[%v].FetchDbCredentials(%s, %s)\n", z, engineMountPath, roleName)
+
+	if err := z.login(); err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+
+	path := fmt.Sprintf("%s/creds/%s", engineMountPath, roleName)
+
+	secret, err := z.client.Logical().ReadWithContext(context.Background(), path)
+	if err != nil {
+		// until we got a better way of detecting it, just logout on error (auth token might be expired)
+		z.logout()
+
+		return nil, err
+	}
+
+	if secret == nil {
+		return nil, os.ErrNotExist
+	}
+
+	return secret, nil
+}
+
+func (z *VaultClient) IssuePkiCertificate(engineMountPath string, roleName string, params map[string]interface{}) (*vaultApi.Secret, error) {
+	util.Tracef("VaultClient[%v].IssuePkiCertificate(%s, %s)\n", z, engineMountPath, roleName)
+
+	return z.writePkiCertificate(fmt.Sprintf("%s/issue/%s", engineMountPath, roleName), params)
+}
+
+// SignPkiCertificate signs the certificate request given in the csr param.
+func (z *VaultClient) SignPkiCertificate(engineMountPath string, roleName string, params map[string]interface{}) (*vaultApi.Secret, error) {
+	util.Tracef("VaultClient[%v].SignPkiCertificate(%s, %s)\n", z, engineMountPath, roleName)
+
+	return z.writePkiCertificate(fmt.Sprintf("%s/sign/%s", engineMountPath, roleName), params)
+}
+
+func (z *VaultClient) writePkiCertificate(path string, params map[string]interface{}) (*vaultApi.Secret, error) {
+	if err := z.login(); err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+
+	secret, err := z.client.Logical().WriteWithContext(context.Background(), path, params)
+	if err != nil {
+		// until we got a better way of detecting it, just logout on error (auth token might be expired)
+		z.logout()
+
+		return nil, err
+	}
+
+	if secret == nil {
+		return nil, os.ErrNotExist
+	}
+
+	return secret, nil
+}
+
+// FetchLogicalSecret reads the path, or writes the params to it when they are
+// defined.
+func (z *VaultClient) FetchLogicalSecret(path string, params map[string]interface{}) (*vaultApi.Secret, error) {
+	util.Tracef("VaultClient[%v].FetchLogicalSecret(%s)\n", z, path)
+
+	if err := z.login(); err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+
+	var secret *vaultApi.Secret
+	var err error
+
+	if params == nil {
+		secret, err = z.client.Logical().ReadWithContext(context.Background(), path)
+	} else {
+		secret, err = z.client.Logical().WriteWithContext(context.Background(), path, params)
+	}
+
+	if err != nil {
+		// until we got a better way of detecting it, just logout on error (auth token might be expired)
+		z.logout()
+
+		return nil, err
+	}
+
+	if secret == nil {
+		return nil, os.ErrNotExist
+	}
+
+	return secret, nil
+}
+
+func (z *VaultClient) GenerateTransitDataKey(engineMountPath string, keyName string) (plaintext string, ciphertext string, err error) {
+	util.Tracef("VaultClient[%v].GenerateTransitDataKey(%s, %s)\n", z, engineMountPath, keyName)
+
+	if err := z.login(); err != nil {
+		return "", "", fmt.Errorf("login: %w", err)
+	}
+
+	path := fmt.Sprintf("%s/datakey/plaintext/%s", engineMountPath, keyName)
+
+	secret, err := z.client.Logical().WriteWithContext(context.Background(), path, map[string]interface{}{
+		"bits": 256,
+	})
+	if err != nil {
+		// until we got a better way of detecting it, just logout on error (auth token might be expired)
+		z.logout()
+
+		return "", "", err
+	}
+
+	if secret == nil {
+		return "", "", os.ErrNotExist
+	}
+
+	plaintext, _ = secret.Data["plaintext"].(string)
+	ciphertext, _ = secret.Data["ciphertext"].(string)
+	if plaintext == "" || ciphertext == "" {
+		return "", "", errors.New("transit data key response is missing the plaintext or ciphertext")
+	}
+
+	return plaintext, ciphertext, nil
+}
+
+func (z *VaultClient) TransitDecrypt(engineMountPath string, keyName string, ciphertext string) (string, error) {
+	util.Tracef("VaultClient[%v].TransitDecrypt(%s, %s)\n", z, engineMountPath, keyName)
+
+	if err := z.login(); err != nil {
+		return "", fmt.Errorf("login: %w", err)
+	}
+
+	path := fmt.Sprintf("%s/decrypt/%s", engineMountPath, keyName)
+
+	secret, err := z.client.Logical().WriteWithContext(context.Background(), path, map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if err != nil {
+		// until we got a better way of detecting it, just logout on error (auth token might be expired)
+		z.logout()
+
+		return "", err
+	}
+
+	if secret == nil {
+		return "", os.ErrNotExist
+	}
+
+	plaintext, _ := secret.Data["plaintext"].(string)
+	if plaintext == "" {
+		return "", errors.New("transit decrypt response is missing the plaintext")
+	}
+
+	return plaintext, nil
+}
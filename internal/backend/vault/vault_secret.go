@@ -7,6 +7,9 @@ package backendVault
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,68 +19,181 @@ import (
 	vaultApi "github.com/hashicorp/vault/api"
 )
 
+var (
+	secretsCacheLock = &sync.Mutex{}
+	secretsCache     = map[string]*VaultSecret{}
+)
+
 type VaultSecret struct {
+	cacheId        string
 	optVaultEngine options.OptVaultEngine
 	optVaultSecret options.OptVaultSecret
 	client         *VaultClient
 
+	refCounter int
+
+	fetchLock *sync.Mutex
+	fetch     *vaultSecretFetch
+
 	cacheLock         *sync.Mutex
 	lifetimeWatcherId *string
 	cacheRefTime      time.Time
 	cacheTtl          time.Duration
+	issued            bool
+	periodic          bool
 	data              *backend.SecretData
+
+	fetchedAt      *time.Time
+	leaseExpiresAt *time.Time
+	version        *string
+	lastError      error
+	lastErrorAt    *time.Time
+}
+
+// vaultSecretFetch is a request to Vault in progress, shared by the concurrent
+// GetData calls.
+type vaultSecretFetch struct {
+	noCache bool
+	done    chan struct{}
+	data    *backend.SecretData
+	err     error
 }
 
 type VaultSecretConfig struct {
 	OptVault options.OptVault
 }
 
+// NewVaultSecret returns the secret of the given options, shared with the
+// volumes and sources using the same options until they all close it.
 func NewVaultSecret(config VaultSecretConfig) (*VaultSecret, error) {
+	util.Tracef("NewVaultSecret(%+v)\n", config)
+
+	secretsCacheLock.Lock()
+	defer secretsCacheLock.Unlock()
+
+	cacheId := config.OptVault.CacheId_()
+
+	secret, ok := secretsCache[cacheId]
+	if ok {
+		util.Tracef("Reusing secret %+v\n", secret)
+		secret.refCounter++
+		return secret, nil
+	}
+
 	client, err := newVaultClient(VaultClientConfig{
-		optClientHttp: config.OptVault.ClientHttp,
-		optVaultAuth:  config.OptVault.VaultAuth,
+		optClientHttp:   config.OptVault.ClientHttp,
+		optVaultAuth:    config.OptVault.VaultAuth,
+		engineNamespace: config.OptVault.VaultEngine.Namespace,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create vault client: %w", err)
 	}
 
-	return &VaultSecret{
+	secret = &VaultSecret{
+		cacheId:        cacheId,
 		optVaultEngine: config.OptVault.VaultEngine,
 		optVaultSecret: config.OptVault.VaultSecret,
 		client:         client,
 
+		refCounter: 1,
+
+		fetchLock: &sync.Mutex{},
+
 		cacheLock: &sync.Mutex{},
-	}, nil
+	}
+
+	util.Tracef("Creating new secret %+v\n", secret)
+	secretsCache[cacheId] = secret
+
+	return secret, nil
 }
 
 func (z *VaultSecret) Close() {
-	z.cacheLock.Lock()
-	z.clearCacheUnsafe()
-	z.cacheLock.Unlock()
+	util.Tracef("VaultSecret[%v].Close()\n", z)
+
+	secretsCacheLock.Lock()
+	defer secretsCacheLock.Unlock()
+
+	z.refCounter--
+	if z.refCounter == 0 {
+		delete(secretsCache, z.cacheId)
 
-	z.client.Close()
-	z.client = nil
+		z.cacheLock.Lock()
+		z.clearCacheUnsafe()
+		z.cacheLock.Unlock()
+
+		z.client.Close()
+		z.client = nil
+	}
 }
 
+// GetData returns the secret data, from the cache unless noCache is set. The
+// concurrent calls share the request in progress, when it is at least as
+// fresh as requested.
 func (z *VaultSecret) GetData(noCache bool) (*backend.SecretData, error) {
 	util.Tracef("VaultSecret[%v].GetData(%v)\n", z, noCache)
 
+	z.fetchLock.Lock()
+	if fetch := z.fetch; fetch != nil && (fetch.noCache || !noCache) {
+		z.fetchLock.Unlock()
+
+		<-fetch.done
+		return fetch.data, fetch.err
+	}
+
+	fetch := &vaultSecretFetch{
+		noCache: noCache,
+		done:    make(chan struct{}),
+	}
+	z.fetch = fetch
+	z.fetchLock.Unlock()
+
+	fetch.data, fetch.err = z.getData(noCache)
+
+	z.fetchLock.Lock()
+	if z.fetch == fetch {
+		z.fetch = nil
+	}
+	z.fetchLock.Unlock()
+
+	close(fetch.done)
+
+	return fetch.data, fetch.err
+}
+
+func (z *VaultSecret) getData(noCache bool) (_ *backend.SecretData, err error) {
+
 	z.cacheLock.Lock()
 	defer z.cacheLock.Unlock()
 
-	if !noCache && z.data != nil && (z.cacheTtl == 0 || time.Now().Compare(z.cacheRefTime.Add(z.cacheTtl)) < 0) {
+	// secrets issued on request (eg. dynamic credentials or certificates) are
+	// not requested again before they expire, even when bypassing the cache,
+	// nor the secrets refreshed periodically
+	if (!noCache || z.issued || z.periodic) && z.data != nil && (z.cacheTtl == 0 || time.Now().Compare(z.cacheRefTime.Add(z.cacheTtl)) < 0) {
 		return z.data, nil
 	}
 
+	defer func() {
+		z.setLastErrorUnsafe(err)
+	}()
+
 	z.clearCacheUnsafe()
 
 	var data *VaultSecretData
-	var err error
 
 	switch z.optVaultEngine.Type {
 	case options.VaultEngineTypeKv:
 		data, err = z.getKvData()
 
+	case options.VaultEngineTypeDb:
+		data, err = z.getDbData()
+
+	case options.VaultEngineTypePki:
+		data, err = z.getPkiData()
+
+	case options.VaultEngineTypeGeneric:
+		data, err = z.getGenericData()
+
 	default:
 		return nil, errors.New("not implemented")
 	}
@@ -91,7 +207,9 @@ func (z *VaultSecret) GetData(noCache bool) (*backend.SecretData, error) {
 	}
 
 	if data.secret.Renewable {
-		lifetimeWatcherId, err := z.client.NewLifetimeWatcher(vaultApi.LifetimeWatcherInput{
+		var lifetimeWatcherId *string
+
+		lifetimeWatcherId, err = z.client.NewLifetimeWatcher(vaultApi.LifetimeWatcherInput{
 			Secret:    data.secret,
 			Increment: z.optVaultSecret.TokenRenewTtl,
 		}, func(err error) {
@@ -99,9 +217,30 @@ func (z *VaultSecret) GetData(noCache bool) (*backend.SecretData, error) {
 				util.Errorf("Unable to renew vault data secret %v: %v\n", z, err)
 			}
 
-			z.clearCacheUnsafe()
+			z.cacheLock.Lock()
+			defer z.cacheLock.Unlock()
+
+			if err != nil {
+				z.setLastErrorUnsafe(fmt.Errorf("renew secret: %w", err))
+			}
+
+			// the lease is over: drop the data so that the next call requests a new one
+			if z.lifetimeWatcherId != nil && lifetimeWatcherId != nil && *z.lifetimeWatcherId == *lifetimeWatcherId {
+				z.lifetimeWatcherId = nil
+				z.data = nil
+			}
 		}, func(renewal *vaultApi.RenewOutput) {
 			util.Tracef("Renewed vault data secret %v: %+v\n", z, renewal)
+
+			if renewal.Secret == nil || renewal.Secret.LeaseDuration <= 0 {
+				return
+			}
+
+			z.cacheLock.Lock()
+			defer z.cacheLock.Unlock()
+
+			leaseExpiresAt := renewal.RenewedAt.Add(time.Duration(renewal.Secret.LeaseDuration) * time.Second)
+			z.leaseExpiresAt = &leaseExpiresAt
 		})
 
 		z.lifetimeWatcherId = lifetimeWatcherId
@@ -115,26 +254,251 @@ func (z *VaultSecret) GetData(noCache bool) (*backend.SecretData, error) {
 	z.data = &_data
 	z.cacheRefTime = data.receivedAt
 	z.cacheTtl = data.cacheTtl
+	z.issued = data.issued
+	z.periodic = data.periodic
+	z.fetchedAt = &data.receivedAt
+	z.leaseExpiresAt = data.leaseExpiresAt
+	z.version = data.version
 
 	return z.data, nil
 }
 
+func (z *VaultSecret) Status() backend.SecretStatus {
+	z.cacheLock.Lock()
+	defer z.cacheLock.Unlock()
+
+	r := backend.SecretStatus{
+		FetchedAt:      z.fetchedAt,
+		LeaseExpiresAt: z.leaseExpiresAt,
+		Version:        z.version,
+		LastError:      z.lastError,
+		LastErrorAt:    z.lastErrorAt,
+	}
+
+	if z.data != nil && z.cacheTtl > 0 {
+		expiresAt := z.cacheRefTime.Add(z.cacheTtl)
+		r.ExpiresAt = &expiresAt
+	}
+
+	return r
+}
+
+func (z *VaultSecret) setLastErrorUnsafe(err error) {
+	if err == nil {
+		z.lastError = nil
+		z.lastErrorAt = nil
+		return
+	}
+
+	now := time.Now()
+	z.lastError = err
+	z.lastErrorAt = &now
+}
+
 func (z *VaultSecret) getKvData() (*VaultSecretData, error) {
-	var kvSecret *vaultApi.KVSecret
-	var err error
+	if z.optVaultSecret.Recursive {
+		return z.getKvTreeData()
+	}
+
+	kvSecret, err := z.fetchKvSecret(z.optVaultSecret.Path, z.optVaultSecret.KvVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := NewVaultSecretDataFromKVSecret(*kvSecret, z.optVaultSecret.NestedValues)
+	if err != nil {
+		return nil, err
+	}
 
+	return data, nil
+}
+
+func (z *VaultSecret) fetchKvSecret(secretPath string, secretVersion *int) (*vaultApi.KVSecret, error) {
 	switch z.optVaultEngine.KvVersion {
 	case 1:
-		kvSecret, err = z.client.FetchKVv1Secret(z.optVaultEngine.EffectiveMountPath(), z.optVaultSecret.Path)
+		return z.client.FetchKVv1Secret(z.optVaultEngine.EffectiveMountPath(), secretPath)
 	case 2:
-		kvSecret, err = z.client.FetchKVv2Secret(z.optVaultEngine.EffectiveMountPath(), z.optVaultSecret.Path, z.optVaultSecret.KvVersion)
+		return z.client.FetchKVv2Secret(z.optVaultEngine.EffectiveMountPath(), secretPath, secretVersion)
+	}
+
+	return nil, fmt.Errorf("KV engine version %d not implemented", z.optVaultEngine.KvVersion)
+}
+
+func (z *VaultSecret) getKvTreeData() (*VaultSecretData, error) {
+	kvSecrets := map[string]vaultApi.KVSecret{}
+
+	if err := z.fetchKvTree(strings.Trim(z.optVaultSecret.Path, "/"), "", kvSecrets); err != nil {
+		return nil, err
+	}
+
+	data, err := NewVaultSecretDataFromKVSecrets(kvSecrets, z.optVaultSecret.NestedValues, time.Duration(z.optVaultSecret.RecursiveRefreshInterval)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// fetchKvTree fetches the secrets listed under prefix/relPath, recursively,
+// indexed by their path relative to prefix.
+func (z *VaultSecret) fetchKvTree(prefix string, relPath string, kvSecrets map[string]vaultApi.KVSecret) error {
+	keys, err := z.client.ListKVSecrets(z.optVaultEngine.EffectiveMountPath(), z.optVaultEngine.KvVersion, path.Join(prefix, relPath))
+	if err != nil {
+		return fmt.Errorf("list %s secrets: %w", path.Join(prefix, relPath), err)
+	}
+
+	for _, key := range keys {
+		keyRelPath := path.Join(relPath, key)
+
+		if strings.HasSuffix(key, "/") {
+			if err := z.fetchKvTree(prefix, keyRelPath, kvSecrets); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+
+			continue
+		}
+
+		kvSecret, err := z.fetchKvSecret(path.Join(prefix, keyRelPath), nil)
+		if err != nil {
+			// deleted since listed, or deleted KV v2 latest version
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return fmt.Errorf("fetch %s secret: %w", path.Join(prefix, keyRelPath), err)
+		}
+
+		kvSecrets[keyRelPath] = *kvSecret
+	}
+
+	return nil
+}
+
+func (z *VaultSecret) getDbData() (*VaultSecretData, error) {
+	secret, err := z.client.FetchDbCredentials(z.optVaultEngine.EffectiveMountPath(), z.optVaultSecret.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := NewVaultSecretDataFromDbSecret(*secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// signPkiCertificate generates a private key of the given type and gets its
+// certificate signed, returning it along with the private key like the issue
+// endpoint does.
+func (z *VaultSecret) signPkiCertificate(params map[string]interface{}, keyType string, pkcs8 bool) (*vaultApi.Secret, error) {
+	key, err := util.NewPrivateKey(keyType, z.optVaultSecret.EffectivePkiKeyBits())
+	if err != nil {
+		return nil, fmt.Errorf("generate private key: %w", err)
+	}
+
+	commonName := ""
+	if z.optVaultSecret.PkiCommonName != nil {
+		commonName = *z.optVaultSecret.PkiCommonName
+	}
+
+	csr, err := util.NewCertificateRequestPem(key, commonName)
+	if err != nil {
+		return nil, fmt.Errorf("create certificate request: %w", err)
+	}
+
+	privateKey, err := util.EncodePrivateKeyPem(key, pkcs8)
+	if err != nil {
+		return nil, fmt.Errorf("encode private key: %w", err)
+	}
+
+	params["csr"] = csr
+
+	secret, err := z.client.SignPkiCertificate(z.optVaultEngine.EffectiveMountPath(), z.optVaultSecret.Path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string]interface{}{}
+	}
+
+	secret.Data["private_key"] = privateKey
+	secret.Data["private_key_type"] = keyType
+
+	return secret, nil
+}
+
+func (z *VaultSecret) getPkiData() (*VaultSecretData, error) {
+	params := map[string]interface{}{}
+
+	if z.optVaultSecret.PkiCommonName != nil {
+		params["common_name"] = *z.optVaultSecret.PkiCommonName
+	}
+	if len(z.optVaultSecret.PkiAltNames) > 0 {
+		params["alt_names"] = strings.Join(z.optVaultSecret.PkiAltNames, ",")
+	}
+	if len(z.optVaultSecret.PkiIpSans) > 0 {
+		params["ip_sans"] = strings.Join(z.optVaultSecret.PkiIpSans, ",")
+	}
+	if z.optVaultSecret.PkiTtl != nil {
+		params["ttl"] = *z.optVaultSecret.PkiTtl
+	}
+	// the certificates and the private key are written as PEM files, the
+	// private key being PKCS#1/SEC1 (Vault "der" private key format) unless
+	// PKCS#8 is requested
+	params["format"] = "pem"
+	pkcs8 := z.optVaultSecret.PkiPrivateKeyFormat != nil && *z.optVaultSecret.PkiPrivateKeyFormat == options.PkiPrivateKeyFormatPkcs8
+
+	var secret *vaultApi.Secret
+	var err error
+
+	if z.optVaultSecret.PkiKeyType == nil {
+		if pkcs8 {
+			params["private_key_format"] = options.PkiPrivateKeyFormatPkcs8
+		}
+
+		secret, err = z.client.IssuePkiCertificate(z.optVaultEngine.EffectiveMountPath(), z.optVaultSecret.Path, params)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// the issue endpoint only generates keys of the role key type: generate
+		// the key and have its certificate request signed instead
+		secret, err = z.signPkiCertificate(params, *z.optVaultSecret.PkiKeyType, pkcs8)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := NewVaultSecretDataFromPkiSecret(*secret, z.optVaultSecret.EffectivePkiReissueRatio())
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (z *VaultSecret) getGenericData() (*VaultSecretData, error) {
+	secretPath := z.optVaultSecret.Path
+	if mountPath := z.optVaultEngine.EffectiveMountPath(); mountPath != "" {
+		secretPath = path.Join(mountPath, secretPath)
+	}
+
+	var params map[string]interface{}
+	if z.optVaultSecret.EngineParams != nil {
+		params = map[string]interface{}{}
+		for k, v := range z.optVaultSecret.EngineParams {
+			params[k] = v
+		}
 	}
 
+	secret, err := z.client.FetchLogicalSecret(secretPath, params)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := NewVaultSecretDataFromKVSecret(*kvSecret)
+	data, err := NewVaultSecretDataFromLogicalSecret(*secret, params != nil, z.optVaultSecret.NestedValues)
 	if err != nil {
 		return nil, err
 	}
@@ -151,4 +515,5 @@ func (z *VaultSecret) clearCacheUnsafe() {
 		z.client.CloseLifetimeWatcher(*id)
 	}
 
+	z.data = nil
 }
@@ -5,12 +5,16 @@
 package backendVault
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
 	"github.com/anthochamp/docker-plugin-vaultfs/internal/constants"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
 	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
 	"github.com/google/uuid"
 	vaultApi "github.com/hashicorp/vault/api"
@@ -22,9 +26,17 @@ type VaultSecretData struct {
 	uniqueId   string
 	receivedAt time.Time
 	cacheTtl   time.Duration
+	issued     bool // issued on request (eg. dynamic credentials, certificates)
+	periodic   bool // refreshed once cacheTtl elapsed only (eg. KV subtree)
 
-	data      map[string]string
-	createdAt *time.Time
+	leaseExpiresAt *time.Time
+	version        *string
+
+	data            map[string]string
+	metadata        map[string]string
+	fieldTransforms map[string][]string
+	fieldAttrs      map[string]backend.FieldAttr
+	createdAt       *time.Time
 }
 
 func (z VaultSecretData) UniqueId() string { return z.uniqueId }
@@ -48,41 +60,81 @@ func (z VaultSecretData) GetValue(key string) (*string, bool) {
 	return &value, true
 }
 
-func NewVaultSecretDataFromKVSecret(kvSecret vaultApi.KVSecret) (*VaultSecretData, error) {
+func (z VaultSecretData) Metadata() map[string]string { return z.metadata }
+
+func (z VaultSecretData) FieldTransforms() map[string][]string { return z.fieldTransforms }
+
+func (z VaultSecretData) FieldAttrs() map[string]backend.FieldAttr { return z.fieldAttrs }
+
+func NewVaultSecretDataFromKVSecret(kvSecret vaultApi.KVSecret, nestedValues string) (*VaultSecretData, error) {
 	var createdAt *time.Time
 	if kvSecret.VersionMetadata != nil {
 		createdAt = &kvSecret.VersionMetadata.CreatedTime
 	}
 
-	var data map[string]string
-	if kvSecret.Data == nil {
-		data = map[string]string{}
-	} else {
-		data = util.MapStringStringFromMapStringInterface(kvSecret.Data)
-	}
+	data := dataFromSecretValues(kvSecret.Data, nestedValues)
+	metadata := map[string]string{}
+
+	var version *string
 
 	if kvSecret.VersionMetadata != nil {
-		data[".version-metadata-created-at"] = kvSecret.VersionMetadata.CreatedTime.UTC().Format(time.RFC3339)
-		data[".version-metadata-deleted-at"] = kvSecret.VersionMetadata.DeletionTime.UTC().Format(time.RFC3339)
-		data[".version-metadata-is-destroyed"] = strconv.FormatBool(kvSecret.VersionMetadata.Destroyed)
-		data[".version-metadata-version"] = strconv.Itoa(kvSecret.VersionMetadata.Version)
+		v := strconv.Itoa(kvSecret.VersionMetadata.Version)
+		version = &v
+
+		metadata[".version-metadata-created-at"] = kvSecret.VersionMetadata.CreatedTime.UTC().Format(time.RFC3339)
+		metadata[".version-metadata-deleted-at"] = kvSecret.VersionMetadata.DeletionTime.UTC().Format(time.RFC3339)
+		metadata[".version-metadata-is-destroyed"] = strconv.FormatBool(kvSecret.VersionMetadata.Destroyed)
+		metadata[".version-metadata-version"] = v
 	}
 
 	// TODO: default cache ttl
 	var cacheTtl time.Duration = 0
 
+	fieldTransforms := map[string][]string{}
+	fieldAttrs := map[string]backend.FieldAttr{}
+
 	if kvSecret.CustomMetadata != nil {
 		for k, v := range kvSecret.CustomMetadata {
 			if strings.HasPrefix(k, constants.AppName+"-") {
 				if k == constants.AppName+"-cache-ttl" {
-					cacheTtli, err := strconv.Atoi(v.(string))
+					cacheTtli, err := strconv.Atoi(util.StringFromInterface(v))
 					if err != nil {
-						return nil, fmt.Errorf("unable to convert %s value %v to int", constants.AppName+"-cache-ttl", cacheTtli)
+						return nil, fmt.Errorf("unable to convert %s value %v to int", constants.AppName+"-cache-ttl", v)
 					}
 					cacheTtl = time.Duration(cacheTtli) * time.Second
+				} else if field, ok := strings.CutPrefix(k, constants.AppName+"-field-transform."); ok {
+					transforms := util.SplitList(strings.ToLower(util.StringFromInterface(v)))
+					if err := options.ValidateFieldTransforms(transforms); err != nil {
+						return nil, fmt.Errorf("%s: %w", k, err)
+					}
+					fieldTransforms[field] = transforms
+				} else if field, ok := strings.CutPrefix(k, constants.AppName+"-owner."); ok {
+					uid, err := util.UserIdFromUser(util.StringFromInterface(v))
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", k, err)
+					}
+					fieldAttr := fieldAttrs[field]
+					fieldAttr.UId = &uid
+					fieldAttrs[field] = fieldAttr
+				} else if field, ok := strings.CutPrefix(k, constants.AppName+"-group."); ok {
+					gid, err := util.UserGroupIdFromUserGroup(util.StringFromInterface(v))
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", k, err)
+					}
+					fieldAttr := fieldAttrs[field]
+					fieldAttr.GId = &gid
+					fieldAttrs[field] = fieldAttr
+				} else if field, ok := strings.CutPrefix(k, constants.AppName+"-mode."); ok {
+					mode, err := options.ParseFieldMode(util.StringFromInterface(v))
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", k, err)
+					}
+					fieldAttr := fieldAttrs[field]
+					fieldAttr.Mode = &mode
+					fieldAttrs[field] = fieldAttr
 				}
 			} else {
-				data[".metadata-"+k] = v.(string)
+				metadata[".metadata-"+k] = util.StringFromInterface(v)
 			}
 		}
 	}
@@ -94,7 +146,310 @@ func NewVaultSecretDataFromKVSecret(kvSecret vaultApi.KVSecret) (*VaultSecretDat
 		receivedAt: time.Now(),
 		cacheTtl:   cacheTtl,
 
+		version: version,
+
+		data:            data,
+		metadata:        metadata,
+		fieldTransforms: fieldTransforms,
+		fieldAttrs:      fieldAttrs,
+		createdAt:       createdAt,
+	}, nil
+}
+
+// NewVaultSecretDataFromKVSecrets merges the secrets of a KV subtree, each
+// field being prefixed by the path of its secret.
+func NewVaultSecretDataFromKVSecrets(kvSecrets map[string]vaultApi.KVSecret, nestedValues string, refreshInterval time.Duration) (*VaultSecretData, error) {
+	data := map[string]string{}
+	metadata := map[string]string{}
+	fieldTransforms := map[string][]string{}
+	fieldAttrs := map[string]backend.FieldAttr{}
+	cacheTtl := refreshInterval
+
+	var createdAt *time.Time
+
+	for secretPath, kvSecret := range kvSecrets {
+		secretData, err := NewVaultSecretDataFromKVSecret(kvSecret, nestedValues)
+		if err != nil {
+			return nil, fmt.Errorf("%s secret: %w", secretPath, err)
+		}
+
+		for k, v := range secretData.data {
+			data[secretPath+"/"+k] = v
+		}
+
+		for k, v := range secretData.metadata {
+			metadata[secretPath+"/"+k] = v
+		}
+
+		for k, v := range secretData.fieldTransforms {
+			fieldTransforms[secretPath+"/"+k] = v
+		}
+
+		for k, v := range secretData.fieldAttrs {
+			fieldAttrs[secretPath+"/"+k] = v
+		}
+
+		if secretData.cacheTtl > 0 && secretData.cacheTtl < cacheTtl {
+			cacheTtl = secretData.cacheTtl
+		}
+
+		if secretData.createdAt != nil && (createdAt == nil || secretData.createdAt.After(*createdAt)) {
+			createdAt = secretData.createdAt
+		}
+	}
+
+	return &VaultSecretData{
+		secret: &vaultApi.Secret{},
+
+		uniqueId:   uuid.New().String(),
+		receivedAt: time.Now(),
+		cacheTtl:   cacheTtl,
+		periodic:   true,
+
+		data:            data,
+		metadata:        metadata,
+		fieldTransforms: fieldTransforms,
+		fieldAttrs:      fieldAttrs,
+		createdAt:       createdAt,
+	}, nil
+}
+
+func NewVaultSecretDataFromDbSecret(secret vaultApi.Secret) (*VaultSecretData, error) {
+	data := map[string]string{}
+
+	for _, k := range []string{"username", "password"} {
+		s, err := stringFromSecretData(secret.Data, k)
+		if err != nil {
+			return nil, fmt.Errorf("database credentials: %w", err)
+		}
+
+		data[k] = s
+	}
+
+	metadata := map[string]string{}
+	addLeaseMetadata(metadata, secret)
+
+	receivedAt := time.Now()
+
+	// renewable leases are handled by a lifetime watcher, others must be
+	// requested again once they expire
+	var cacheTtl time.Duration = 0
+	if !secret.Renewable && secret.LeaseDuration > 0 {
+		cacheTtl = time.Duration(secret.LeaseDuration) * time.Second
+	}
+
+	return &VaultSecretData{
+		secret: &secret,
+
+		uniqueId:   uuid.New().String(),
+		receivedAt: receivedAt,
+		cacheTtl:   cacheTtl,
+		issued:     true,
+
+		leaseExpiresAt: leaseExpiresAt(secret, receivedAt),
+
 		data:      data,
-		createdAt: createdAt,
+		metadata:  metadata,
+		createdAt: &receivedAt,
 	}, nil
 }
+
+func NewVaultSecretDataFromPkiSecret(secret vaultApi.Secret, reissueRatio float64) (*VaultSecretData, error) {
+	certificate, err := stringFromSecretData(secret.Data, "certificate")
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := stringFromSecretData(secret.Data, "private_key")
+	if err != nil {
+		return nil, err
+	}
+
+	issuingCa, err := stringFromSecretData(secret.Data, "issuing_ca")
+	if err != nil {
+		return nil, err
+	}
+
+	caChain := []string{}
+	if v, ok := secret.Data["ca_chain"].([]interface{}); ok {
+		for _, c := range v {
+			s, ok := c.(string)
+			if !ok {
+				return nil, errors.New("PKI certificate ca_chain field is not a list of strings")
+			}
+
+			caChain = append(caChain, s)
+		}
+	}
+
+	if len(caChain) == 0 {
+		caChain = append(caChain, issuingCa)
+	}
+
+	expiration, err := int64FromSecretData(secret.Data, "expiration")
+	if err != nil {
+		return nil, err
+	}
+
+	receivedAt := time.Now()
+	expiresAt := time.Unix(expiration, 0)
+
+	// certificates are not renewable: issue a new one once the given
+	// fraction of its remaining lifetime has elapsed
+	cacheTtl := time.Duration(float64(expiresAt.Sub(receivedAt)) * reissueRatio)
+	if cacheTtl <= 0 {
+		cacheTtl = time.Second
+	}
+
+	data := map[string]string{
+		"certificate.pem": pemWithTrailingNewline(certificate),
+		"private_key.pem": pemWithTrailingNewline(privateKey),
+		"issuing_ca.pem":  pemWithTrailingNewline(issuingCa),
+		"ca_chain.pem":    "",
+		"bundle.pem":      pemWithTrailingNewline(certificate),
+	}
+
+	for _, c := range caChain {
+		data["ca_chain.pem"] += pemWithTrailingNewline(c)
+	}
+
+	data["bundle.pem"] += data["ca_chain.pem"] + data["private_key.pem"]
+
+	metadata := map[string]string{}
+
+	if v, ok := secret.Data["serial_number"].(string); ok {
+		metadata[".serial-number"] = v
+	}
+
+	if v, ok := secret.Data["private_key_type"].(string); ok {
+		metadata[".private-key-type"] = v
+	}
+
+	metadata[".expiration"] = expiresAt.UTC().Format(time.RFC3339)
+
+	if secret.LeaseID != "" {
+		addLeaseMetadata(metadata, secret)
+	}
+
+	return &VaultSecretData{
+		secret: &secret,
+
+		uniqueId:   uuid.New().String(),
+		receivedAt: receivedAt,
+		cacheTtl:   cacheTtl,
+		issued:     true,
+
+		leaseExpiresAt: &expiresAt,
+
+		data:      data,
+		metadata:  metadata,
+		createdAt: &receivedAt,
+	}, nil
+}
+
+func NewVaultSecretDataFromLogicalSecret(secret vaultApi.Secret, written bool, nestedValues string) (*VaultSecretData, error) {
+	data := dataFromSecretValues(secret.Data, nestedValues)
+	metadata := map[string]string{}
+
+	if secret.LeaseID != "" {
+		addLeaseMetadata(metadata, secret)
+	}
+
+	receivedAt := time.Now()
+
+	// renewable leases are handled by a lifetime watcher, others must be
+	// requested again once they expire
+	var cacheTtl time.Duration = 0
+	if !secret.Renewable && secret.LeaseDuration > 0 {
+		cacheTtl = time.Duration(secret.LeaseDuration) * time.Second
+	}
+
+	return &VaultSecretData{
+		secret: &secret,
+
+		uniqueId:   uuid.New().String(),
+		receivedAt: receivedAt,
+		cacheTtl:   cacheTtl,
+		// leased secrets and write responses are generated on request
+		issued: written || secret.LeaseID != "",
+
+		leaseExpiresAt: leaseExpiresAt(secret, receivedAt),
+
+		data:      data,
+		metadata:  metadata,
+		createdAt: &receivedAt,
+	}, nil
+}
+
+// dataFromSecretValues renders the secret values as fields, the objects
+// being either JSON encoded or expanded as "parent/child" fields.
+func dataFromSecretValues(values map[string]interface{}, nestedValues string) map[string]string {
+	if values == nil {
+		return map[string]string{}
+	}
+
+	if nestedValues == options.NestedValuesDir {
+		return util.FlattenMapStringInterface(values, "/")
+	}
+
+	return util.MapStringStringFromMapStringInterface(values)
+}
+
+func stringFromSecretData(data map[string]interface{}, key string) (string, error) {
+	v, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret is missing the %s field", key)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("secret %s field is not a string", key)
+	}
+
+	return s, nil
+}
+
+func int64FromSecretData(data map[string]interface{}, key string) (int64, error) {
+	v, ok := data[key]
+	if !ok {
+		return 0, fmt.Errorf("secret is missing the %s field", key)
+	}
+
+	switch n := v.(type) {
+	case json.Number:
+		return n.Int64()
+	case float64:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	}
+
+	return 0, fmt.Errorf("secret %s field is not a number", key)
+}
+
+func pemWithTrailingNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s
+	}
+
+	return s + "\n"
+}
+
+// leaseExpiresAt returns the expiration time of a leased secret, before it is
+// renewed.
+func leaseExpiresAt(secret vaultApi.Secret, receivedAt time.Time) *time.Time {
+	if secret.LeaseID == "" || secret.LeaseDuration <= 0 {
+		return nil
+	}
+
+	r := receivedAt.Add(time.Duration(secret.LeaseDuration) * time.Second)
+	return &r
+}
+
+func addLeaseMetadata(metadata map[string]string, secret vaultApi.Secret) {
+	metadata[".lease-id"] = secret.LeaseID
+	metadata[".lease-duration"] = strconv.Itoa(secret.LeaseDuration)
+	metadata[".lease-renewable"] = strconv.FormatBool(secret.Renewable)
+}
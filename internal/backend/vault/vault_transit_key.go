@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package backendVault
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+)
+
+// VaultTransitKey wraps and unwraps data keys with a Vault Transit engine key
+// (envelope encryption).
+type VaultTransitKey struct {
+	mountPath string
+	name      string
+	client    *VaultClient
+}
+
+type VaultTransitKeyConfig struct {
+	OptVault  options.OptVault
+	MountPath string
+	Name      string
+}
+
+func NewVaultTransitKey(config VaultTransitKeyConfig) (*VaultTransitKey, error) {
+	client, err := newVaultClient(VaultClientConfig{
+		optClientHttp:   config.OptVault.ClientHttp,
+		optVaultAuth:    config.OptVault.VaultAuth,
+		engineNamespace: config.OptVault.VaultEngine.Namespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create vault client: %w", err)
+	}
+
+	return &VaultTransitKey{
+		mountPath: config.MountPath,
+		name:      config.Name,
+		client:    client,
+	}, nil
+}
+
+func (z *VaultTransitKey) Close() {
+	z.client.Close()
+	z.client = nil
+}
+
+// GenerateDataKey returns a new data key and its wrapped (encrypted) version.
+func (z *VaultTransitKey) GenerateDataKey() ([]byte, string, error) {
+	plaintext, ciphertext, err := z.client.GenerateTransitDataKey(z.mountPath, z.name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(plaintext)
+	if err != nil {
+		return nil, "", fmt.Errorf("decode data key: %w", err)
+	}
+
+	return key, ciphertext, nil
+}
+
+// UnwrapDataKey returns the data key from its wrapped (encrypted) version.
+func (z *VaultTransitKey) UnwrapDataKey(wrappedKey string) ([]byte, error) {
+	plaintext, err := z.client.TransitDecrypt(z.mountPath, z.name, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("decode data key: %w", err)
+	}
+
+	return key, nil
+}
@@ -10,4 +10,6 @@ const (
 	AppName string = "docker-plugin-" + DockerPluginId
 
 	EnvVarsPrefix string = "DPV_"
+
+	DockerLabelsPrefix string = DockerPluginId + "."
 )
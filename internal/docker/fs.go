@@ -31,6 +31,7 @@ type FsConfig struct {
 	MountDir      string
 	MountDirUId   uint16
 	MountDirGId   uint16
+	TemplateDir   string // volumes templates files directory
 }
 
 func newFs(config FsConfig) *Fs {
@@ -7,8 +7,12 @@ package docker
 import (
 	"context"
 	"errors"
+	"maps"
+	"math/rand/v2"
 	"os"
+	"path"
 	"slices"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -20,22 +24,44 @@ import (
 	"github.com/hanwen/go-fuse/v2/fuse"
 )
 
-type fsInodeSecretChild struct {
-	inodeSecretField *FsInodeSecretField
-	inode            *fs.Inode
-}
+const (
+	// Kubernetes secret volumes compatible layout: the fields are symlinks to
+	// the ..data symlink, itself targeting the current version directory.
+	fsSecretDataLinkName         = "..data"
+	fsSecretVersionDirNameLayout = "..2006_01_02_15_04_05.000000000"
+	fsSecretReservedNamePrefix   = ".."
+	fsSecretNestedFieldSeparator = "/"
+)
 
+// FsInodeSecret is the secret directory. On secret rotation, a new version
+// directory is created and the ..data symlink is swapped to it in one step,
+// so that readers always see a consistent snapshot of the fields.
 type FsInodeSecret struct {
 	fs.Inode
 
 	secret          backend.Secret
 	optDockerVolume options.OptDockerVolume
+	templateDir     string
 
 	ATime *time.Time
 
 	lock       sync.RWMutex
 	secretData backend.SecretData
-	childs     map[string]fsInodeSecretChild
+	version    *fsInodeSecretVersionChild
+	dataLink   *fsInodeSymlinkChild
+	fieldLinks map[string]fsInodeSymlinkChild
+
+	statusLock sync.Mutex
+	statusDir  *fsInodeStatusChild
+
+	notifyLock  sync.Mutex
+	notifyQueue []fsNotification
+	notifying   bool
+	notifySend  func(notifications []fsNotification)
+
+	refreshAfter func(d time.Duration) <-chan time.Time
+	refreshStop  chan struct{}
+	refreshDone  chan struct{}
 }
 
 func (*FsInodeSecret) FileMode() uint32 { return fuse.S_IFDIR }
@@ -61,32 +87,144 @@ func (z *FsInodeSecret) MTime() *time.Time {
 
 func (z *FsInodeSecret) CTime() *time.Time { return z.MTime() }
 
-func NewFsInodeSecret(secret backend.Secret, optDockerVolume options.OptDockerVolume) *FsInodeSecret {
-	util.Tracef("NewFsInodeSecret(%+v, %+v)\n", secret, optDockerVolume)
+func NewFsInodeSecret(secret backend.Secret, optDockerVolume options.OptDockerVolume, templateDir string) *FsInodeSecret {
+	util.Tracef("NewFsInodeSecret(%+v, %+v, %s)\n", secret, optDockerVolume, templateDir)
 
-	return &FsInodeSecret{
+	r := &FsInodeSecret{
 		secret:          secret,
 		optDockerVolume: optDockerVolume,
+		templateDir:     templateDir,
+
+		lock:       sync.RWMutex{},
+		fieldLinks: map[string]fsInodeSymlinkChild{},
 
-		lock:   sync.RWMutex{},
-		childs: map[string]fsInodeSecretChild{},
+		refreshAfter: time.After,
 	}
+
+	r.notifySend = r.notify
+
+	return r
 }
 
 func (z *FsInodeSecret) Close() {
-	z.lock.Lock()
-	defer z.lock.Unlock()
+	if z.refreshStop != nil {
+		close(z.refreshStop)
+		<-z.refreshDone
+
+		z.refreshStop = nil
+		z.refreshDone = nil
+	}
 
+	z.lock.Lock()
 	z.clearCacheUnsafe()
+	z.lock.Unlock()
+
+	z.statusLock.Lock()
+	if z.statusDir != nil {
+		z.statusDir.inodeStatus.forget()
+		z.statusDir.inode.ForgetPersistent()
+		z.statusDir = nil
+	}
+	z.statusLock.Unlock()
+}
+
+// StartRefresh refreshes the secret data in the background, right away then
+// every refresh interval plus a random jitter, so that the FUSE operations are
+// served from memory. It must be called once the inode is added to the tree.
+func (z *FsInodeSecret) StartRefresh() {
+	if z.optDockerVolume.RefreshInterval <= 0 || z.refreshStop != nil {
+		return
+	}
+
+	z.refreshStop = make(chan struct{})
+	z.refreshDone = make(chan struct{})
+
+	go z.refreshLoop(z.refreshStop, z.refreshDone)
+}
+
+func (z *FsInodeSecret) refreshLoop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		z.refresh()
+
+		select {
+		case <-stop:
+			return
+
+		case <-z.refreshAfter(z.refreshDelay()):
+		}
+	}
+}
+
+func (z *FsInodeSecret) refreshDelay() time.Duration {
+	r := time.Duration(z.optDockerVolume.RefreshInterval) * time.Second
+
+	// spread the requests of the volumes sharing the same interval
+	if z.optDockerVolume.RefreshJitter > 0 {
+		r += rand.N(time.Duration(z.optDockerVolume.RefreshJitter) * time.Second)
+	}
+
+	return r
+}
+
+// refresh fetches the secret data bypassing the secret cache. On failure, the
+// last received data are kept, the status directory reporting them as stale,
+// unless the secret no longer exists.
+func (z *FsInodeSecret) refresh() {
+	util.Tracef("FsInodeSecret[%v].refresh()\n", z)
+
+	data, err := z.secret.GetData(true)
+	if err != nil {
+		util.Errorf("Unable to refresh secret data: %v\n", err)
+
+		if errors.Is(err, os.ErrNotExist) {
+			z.lock.Lock()
+			z.clearCacheUnsafe()
+			z.lock.Unlock()
+		}
+
+		return
+	}
+
+	z.updateCache(context.Background(), *data)
+}
+
+// getStatusDir returns the status directory, created on first use.
+func (z *FsInodeSecret) getStatusDir(ctx context.Context) *fsInodeStatusChild {
+	z.statusLock.Lock()
+	defer z.statusLock.Unlock()
+
+	if z.statusDir == nil {
+		inodeStatus := newFsInodeStatus(z)
+
+		z.statusDir = &fsInodeStatusChild{
+			inodeStatus: inodeStatus,
+			inode:       z.NewPersistentInode(ctx, inodeStatus, fs.StableAttr{Mode: inodeStatus.FileMode()}),
+		}
+	}
+
+	return z.statusDir
 }
 
 func (z *FsInodeSecret) clearCacheUnsafe() {
 	z.secretData = nil
 
-	for _, v := range z.childs {
+	if z.version != nil {
+		z.version.inodeSecretDir.forget()
+		z.version.inode.ForgetPersistent()
+		z.version = nil
+	}
+
+	if z.dataLink != nil {
+		z.dataLink.inode.ForgetPersistent()
+		z.dataLink = nil
+	}
+
+	for _, v := range z.fieldLinks {
 		v.inode.ForgetPersistent()
 	}
-	z.childs = map[string]fsInodeSecretChild{}
+	z.fieldLinks = map[string]fsInodeSymlinkChild{}
 }
 
 func (z *FsInodeSecret) updateCache(ctx context.Context, data backend.SecretData) syscall.Errno {
@@ -97,42 +235,312 @@ func (z *FsInodeSecret) updateCache(ctx context.Context, data backend.SecretData
 		return fs.OK
 	}
 
-	z.secretData = data
+	// the kernel caches are invalidated once the new version is in place
+	z.queueNotifications(z.swapVersion(ctx, z.newVersion(ctx, data), data))
+
+	return fs.OK
+}
+
+// newVersion builds the version directory of the secret data, aside from the
+// current one.
+func (z *FsInodeSecret) newVersion(ctx context.Context, data backend.SecretData) *fsInodeSecretVersionChild {
+	keys := slices.DeleteFunc(data.GetKeys(), func(key string) bool {
+		return strings.HasPrefix(key, fsSecretReservedNamePrefix)
+	})
+
+	// the metadata are exposed as extended attributes, and as dotfiles only
+	// for compatibility, the fields taking precedence
+	metadata := data.Metadata()
+	if z.optDockerVolume.MetadataFiles {
+		for key := range metadata {
+			if _, ok := data.GetValue(key); !ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	// parents come first, so that a field wins over the nested fields sharing
+	// its name
+	slices.Sort(keys)
 
-	keys := data.GetKeys()
+	inodeSecretDir := newFsInodeSecretDir(z.optDockerVolume, data, secretDataXattrs(data, "", nil))
+	version := &fsInodeSecretVersionChild{
+		name:           time.Now().UTC().Format(fsSecretVersionDirNameLayout),
+		inodeSecretDir: inodeSecretDir,
+		inode:          z.NewPersistentInode(ctx, inodeSecretDir, fs.StableAttr{Mode: inodeSecretDir.FileMode()}),
+	}
 
-	childs := map[string]fsInodeSecretChild{}
+	// the rendered templates, then the aggregate formats, take precedence
+	// over the fields
+	for _, rendered := range []map[string][]byte{
+		renderVolumeTemplates(z.optDockerVolume, z.templateDir, data),
+		renderVolumeFormats(z.optDockerVolume, data),
+	} {
+		for _, name := range slices.Sorted(maps.Keys(rendered)) {
+			z.addVersionField(ctx, inodeSecretDir, name, rendered[name], data, volumeFileAttr(z.optDockerVolume, data, name, ""))
+		}
+	}
 
 	for _, key := range keys {
+		names := z.optDockerVolume.FieldNames(key)
+		if len(names) == 0 {
+			continue
+		}
+
 		value, ok := data.GetValue(key)
 		if !ok {
+			metadataValue, ok := metadata[key]
+			if !ok {
+				continue
+			}
+
+			value = &metadataValue
+		}
+
+		transforms := fieldTransforms(z.optDockerVolume, data, key)
+
+		for _, name := range names {
+			fields, err := transformField(name, *value, transforms)
+			if err != nil {
+				util.Errorf("Ignoring secret field %s: %v\n", key, err)
+				continue
+			}
+
+			for _, fieldName := range slices.Sorted(maps.Keys(fields)) {
+				z.addVersionField(ctx, inodeSecretDir, fieldName, fields[fieldName], data, volumeFileAttr(z.optDockerVolume, data, name, key))
+			}
+		}
+	}
+
+	return version
+}
+
+// swapVersion makes the version directory the current one, and returns the
+// kernel cache invalidations of the secret directory. It must be called with
+// the lock held.
+func (z *FsInodeSecret) swapVersion(ctx context.Context, version *fsInodeSecretVersionChild, data backend.SecretData) []fsNotification {
+	var notifications []fsNotification
+
+	// swap the data symlink
+	if z.dataLink == nil {
+		inodeSymlink := newFsInodeSymlink(z.optDockerVolume, version.name, data.CreatedAt())
+
+		z.dataLink = &fsInodeSymlinkChild{
+			inodeSymlink: inodeSymlink,
+			inode:        z.NewPersistentInode(ctx, inodeSymlink, fs.StableAttr{Mode: inodeSymlink.FileMode()}),
+		}
+	} else {
+		z.dataLink.inodeSymlink.SetTarget(version.name, data.CreatedAt())
+
+		notifications = append(notifications, fsNotification{inode: z.dataLink.inode})
+	}
+
+	previousVersion := z.version
+	z.version = version
+	z.secretData = data
+
+	var previousDir *FsInodeSecretDir
+	if previousVersion != nil {
+		previousDir = previousVersion.inodeSecretDir
+	}
+
+	changes := diffFsSecretVersions(previousDir, version.inodeSecretDir)
+
+	// fields symlinks targets never change, only the added and removed fields
+	// are updated
+	for _, key := range changes.added {
+		inodeSymlink := newFsInodeSymlink(z.optDockerVolume, path.Join(fsSecretDataLinkName, key), data.CreatedAt())
+
+		z.fieldLinks[key] = fsInodeSymlinkChild{
+			inodeSymlink: inodeSymlink,
+			inode:        z.NewPersistentInode(ctx, inodeSymlink, fs.StableAttr{Mode: inodeSymlink.FileMode()}),
+		}
+
+		notifications = append(notifications, fsNotification{name: key})
+	}
+
+	for _, key := range changes.changed {
+		fieldLink := z.fieldLinks[key]
+
+		// the field symlink target is the same, but not the file it resolves to
+		fieldLink.inodeSymlink.SetTarget(path.Join(fsSecretDataLinkName, key), data.CreatedAt())
+
+		notifications = append(notifications, fsNotification{inode: fieldLink.inode})
+	}
+
+	for _, key := range changes.removed {
+		fieldLink := z.fieldLinks[key]
+		fieldLink.inode.ForgetPersistent()
+		delete(z.fieldLinks, key)
+
+		notifications = append(notifications, fsNotification{name: key, inode: fieldLink.inode})
+	}
+
+	notifications = append(notifications, fsNotification{name: version.name})
+
+	if previousVersion != nil {
+		previousVersion.inodeSecretDir.forget()
+		previousVersion.inode.ForgetPersistent()
+
+		notifications = append(notifications, fsNotification{name: previousVersion.name, inode: previousVersion.inode})
+	}
+
+	return notifications
+}
+
+// fsSecretVersionChanges are the secret directory entries added, changed or
+// removed between two versions, in name order.
+type fsSecretVersionChanges struct {
+	added   []string
+	changed []string
+	removed []string
+}
+
+// diffFsSecretVersions compares the entries of two version directories, the
+// previous one being nil for the first version. An entry is changed when it
+// resolves to another content or attributes.
+func diffFsSecretVersions(previous *FsInodeSecretDir, next *FsInodeSecretDir) fsSecretVersionChanges {
+	var r fsSecretVersionChanges
+
+	for _, key := range slices.Sorted(maps.Keys(next.childs)) {
+		// the status directory takes precedence
+		if key == fsStatusDirName {
 			continue
 		}
 
-		child, ok := z.childs[key]
-		if !ok {
-			inodeSecretField := newFsInodeSecretField(z.optDockerVolume)
+		var previousChild fsInodeSecretDirChild
+		ok := false
+		if previous != nil {
+			previousChild, ok = previous.childs[key]
+		}
 
-			inode := z.NewPersistentInode(ctx, inodeSecretField, fs.StableAttr{Mode: inodeSecretField.FileMode()})
+		if !ok {
+			r.added = append(r.added, key)
+		} else if !previousChild.equal(next.childs[key]) {
+			r.changed = append(r.changed, key)
+		}
+	}
 
-			child = fsInodeSecretChild{
-				inodeSecretField: inodeSecretField,
-				inode:            inode,
+	if previous != nil {
+		for _, key := range slices.Sorted(maps.Keys(previous.childs)) {
+			if _, ok := next.childs[key]; !ok && key != fsStatusDirName {
+				r.removed = append(r.removed, key)
 			}
 		}
+	}
 
-		child.inodeSecretField.UpdateData([]byte(*value), data)
-		childs[key] = child
+	return r
+}
+
+// fsNotification is a kernel cache invalidation of the secret directory: an
+// added entry (name only), a changed inode (inode only) or a removed entry.
+type fsNotification struct {
+	name  string
+	inode *fs.Inode
+}
+
+// queueNotifications sends the notifications in the background, once the
+// previously queued ones are sent. The order matters when the versions follow
+// each other closely: an entry removed by a version and added back by the next
+// one would otherwise remain deleted from the kernel cache. It is called with
+// the lock held, so that the queue follows the versions order.
+func (z *FsInodeSecret) queueNotifications(notifications []fsNotification) {
+	z.notifyLock.Lock()
+	defer z.notifyLock.Unlock()
+
+	z.notifyQueue = append(z.notifyQueue, notifications...)
+
+	if !z.notifying {
+		z.notifying = true
+
+		go z.sendNotifications()
 	}
+}
+
+func (z *FsInodeSecret) sendNotifications() {
+	for {
+		z.notifyLock.Lock()
+		notifications := z.notifyQueue
+		z.notifyQueue = nil
 
-	for k, v := range z.childs {
-		if !slices.Contains(keys, k) {
-			v.inode.ForgetPersistent()
+		if len(notifications) == 0 {
+			z.notifying = false
+			z.notifyLock.Unlock()
+
+			return
 		}
+		z.notifyLock.Unlock()
+
+		z.notifySend(notifications)
 	}
+}
 
-	z.childs = childs
-	return fs.OK
+// notify invalidates the kernel caches, so that the inotify watchers and the
+// readers see the secret rotation without waiting for the cache timeouts. It
+// must not be called from a FUSE operation, the kernel locking the directory
+// while handling the notifications.
+func (z *FsInodeSecret) notify(notifications []fsNotification) {
+	for _, n := range notifications {
+		var errno syscall.Errno
+
+		switch {
+		case n.inode == nil:
+			errno = z.NotifyEntry(n.name)
+		case n.name == "":
+			errno = n.inode.NotifyContent(0, 0)
+		default:
+			errno = z.NotifyDelete(n.name, n.inode)
+		}
+
+		// the kernel may not know the entry
+		if errno != fs.OK && errno != syscall.ENOENT {
+			util.Tracef("FsInodeSecret[%v].notify(%+v): %v\n", z, n, errno)
+		}
+	}
+}
+
+// addVersionField adds a field to a version directory, the nested fields
+// (eg. "tls/cert") being added to subdirectories.
+func (z *FsInodeSecret) addVersionField(ctx context.Context, inodeSecretDir *FsInodeSecretDir, key string, value []byte, data backend.SecretData, attr backend.FieldAttr) {
+	names := strings.Split(key, fsSecretNestedFieldSeparator)
+
+	if slices.ContainsFunc(names, func(name string) bool { return name == "" || name == "." || name == ".." }) {
+		util.Errorf("Ignoring secret field %s: invalid name\n", key)
+		return
+	}
+
+	for i, name := range names[:len(names)-1] {
+		child, ok := inodeSecretDir.childs[name]
+		if !ok {
+			subInodeSecretDir := newFsInodeSecretDir(z.optDockerVolume, data, secretDataXattrs(data, strings.Join(names[:i+1], fsSecretNestedFieldSeparator), inodeSecretDir.xattrs))
+
+			child = fsInodeSecretDirChild{
+				inodeSecretDir: subInodeSecretDir,
+				inode:          z.NewPersistentInode(ctx, subInodeSecretDir, fs.StableAttr{Mode: subInodeSecretDir.FileMode()}),
+			}
+
+			inodeSecretDir.childs[name] = child
+		} else if child.inodeSecretDir == nil {
+			util.Errorf("Ignoring secret field %s: %s is a field\n", key, name)
+			return
+		}
+
+		inodeSecretDir = child.inodeSecretDir
+	}
+
+	name := names[len(names)-1]
+	if _, ok := inodeSecretDir.childs[name]; ok {
+		util.Errorf("Ignoring secret field %s: duplicate name\n", key)
+		return
+	}
+
+	inodeSecretField := newFsInodeSecretField(z.optDockerVolume, attr, inodeSecretDir.xattrs)
+	inodeSecretField.UpdateData(value, data)
+
+	inodeSecretDir.childs[name] = fsInodeSecretDirChild{
+		inodeSecretField: inodeSecretField,
+		inode:            z.NewPersistentInode(ctx, inodeSecretField, fs.StableAttr{Mode: inodeSecretField.FileMode()}),
+	}
 }
 
 func (z *FsInodeSecret) updateData(ctx context.Context, noCache bool) syscall.Errno {
@@ -155,14 +563,36 @@ func (z *FsInodeSecret) updateData(ctx context.Context, noCache bool) syscall.Er
 	return z.updateCache(ctx, *data)
 }
 
+// loadData makes sure the secret data are loaded. When refreshed in the
+// background, they are served from memory once received, otherwise they are
+// requested according to the secret cache, or bypassing it.
+func (z *FsInodeSecret) loadData(ctx context.Context, noCache bool) syscall.Errno {
+	if z.optDockerVolume.RefreshInterval > 0 {
+		z.lock.RLock()
+		loaded := z.secretData != nil
+		z.lock.RUnlock()
+
+		if loaded {
+			return fs.OK
+		}
+
+		// the background refresh did not succeed yet
+		noCache = false
+	}
+
+	return z.updateData(ctx, noCache)
+}
+
 var _ = (fs.NodeReaddirer)((*FsInodeSecret)(nil))
 var _ = (fs.NodeLookuper)((*FsInodeSecret)(nil))
 var _ = (fs.NodeGetattrer)((*FsInodeSecret)(nil))
+var _ = (fs.NodeGetxattrer)((*FsInodeSecret)(nil))
+var _ = (fs.NodeListxattrer)((*FsInodeSecret)(nil))
 
 func (z *FsInodeSecret) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
 	util.Tracef("FsInodeSecret[%v].Readdir()\n", z)
 
-	if errno := z.updateData(ctx, true); errno != fs.OK {
+	if errno := z.loadData(ctx, true); errno != fs.OK {
 		return nil, errno
 	}
 
@@ -170,9 +600,16 @@ func (z *FsInodeSecret) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 	z.ATime = &now
 
 	z.lock.RLock()
-	r := make([]fuse.DirEntry, 0, len(z.childs))
-	for k, v := range z.childs {
-		r = append(r, fuse.DirEntry{Name: k, Mode: v.inodeSecretField.FileMode()})
+	// the status directory is hidden, so that it is not taken for a field
+	r := make([]fuse.DirEntry, 0, len(z.fieldLinks)+2)
+	if z.dataLink != nil {
+		r = append(r, fuse.DirEntry{Name: fsSecretDataLinkName, Mode: z.dataLink.inodeSymlink.FileMode()})
+	}
+	if z.version != nil {
+		r = append(r, fuse.DirEntry{Name: z.version.name, Mode: z.version.inodeSecretDir.FileMode()})
+	}
+	for _, k := range slices.Sorted(maps.Keys(z.fieldLinks)) {
+		r = append(r, fuse.DirEntry{Name: k, Mode: z.fieldLinks[k].inodeSymlink.FileMode()})
 	}
 	z.lock.RUnlock()
 
@@ -185,26 +622,43 @@ func (z *FsInodeSecret) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 func (z *FsInodeSecret) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
 	util.Tracef("FsInodeSecret[%v].Lookup(%s)\n", z, name)
 
-	if errno := z.updateData(ctx, false); errno != fs.OK {
+	// the status directory is available even when the secret is not
+	if name == fsStatusDirName {
+		statusDir := z.getStatusDir(ctx)
+		statusDir.inodeStatus.fillAttr(&out.Attr)
+
+		out.SetEntryTimeout(1 * time.Second)
+		out.SetAttrTimeout(1 * time.Second)
+
+		return statusDir.inode, fs.OK
+	}
+
+	if errno := z.loadData(ctx, false); errno != fs.OK {
 		return nil, errno
 	}
 
-	child, ok := z.childs[name]
-	if !ok {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+
+	var inode *fs.Inode
+
+	if fieldLink, ok := z.fieldLinks[name]; ok {
+		fieldLink.inodeSymlink.fillAttr(&out.Attr)
+		inode = fieldLink.inode
+	} else if name == fsSecretDataLinkName && z.dataLink != nil {
+		z.dataLink.inodeSymlink.fillAttr(&out.Attr)
+		inode = z.dataLink.inode
+	} else if z.version != nil && name == z.version.name {
+		z.version.inodeSecretDir.fillAttr(&out.Attr)
+		inode = z.version.inode
+	} else {
 		return nil, syscall.ENOENT
 	}
 
-	child.inodeSecretField.lock.RLock()
-	out.Attr.Mode = child.inodeSecretField.AttrMode()
-	out.Attr.Owner = child.inodeSecretField.AttrOwner()
-	out.Attr.Size = uint64(len(child.inodeSecretField.data))
-	out.SetTimes(child.inodeSecretField.ATime, child.inodeSecretField.MTime(), child.inodeSecretField.CTime())
-	child.inodeSecretField.lock.RUnlock()
-
 	out.SetEntryTimeout(1 * time.Second)
 	out.SetAttrTimeout(1 * time.Second)
 
-	return child.inode, fs.OK
+	return inode, fs.OK
 }
 
 func (z *FsInodeSecret) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
@@ -217,3 +671,41 @@ func (z *FsInodeSecret) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse
 
 	return fs.OK
 }
+
+// xattrs returns the extended attributes of the current version.
+func (z *FsInodeSecret) xattrs(ctx context.Context) (map[string][]byte, syscall.Errno) {
+	if errno := z.loadData(ctx, false); errno != fs.OK {
+		return nil, errno
+	}
+
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+
+	if z.version == nil {
+		return nil, fs.OK
+	}
+
+	return z.version.inodeSecretDir.xattrs, fs.OK
+}
+
+func (z *FsInodeSecret) Getxattr(ctx context.Context, attr string, dest []byte) (uint32, syscall.Errno) {
+	util.Tracef("FsInodeSecret[%v].Getxattr(%s)\n", z, attr)
+
+	xattrs, errno := z.xattrs(ctx)
+	if errno != fs.OK {
+		return 0, errno
+	}
+
+	return getxattr(xattrs, attr, dest)
+}
+
+func (z *FsInodeSecret) Listxattr(ctx context.Context, dest []byte) (uint32, syscall.Errno) {
+	util.Tracef("FsInodeSecret[%v].Listxattr()\n", z)
+
+	xattrs, errno := z.xattrs(ctx)
+	if errno != fs.OK {
+		return 0, errno
+	}
+
+	return listxattr(xattrs, dest)
+}
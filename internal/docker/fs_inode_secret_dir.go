@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+type fsInodeSecretVersionChild struct {
+	name           string
+	inodeSecretDir *FsInodeSecretDir
+	inode          *fs.Inode
+}
+
+// fsInodeSecretDirChild is either a field or a subdirectory.
+type fsInodeSecretDirChild struct {
+	inodeSecretField *FsInodeSecretField
+	inodeSecretDir   *FsInodeSecretDir
+	inode            *fs.Inode
+}
+
+func (z fsInodeSecretDirChild) FileMode() uint32 {
+	if z.inodeSecretDir != nil {
+		return z.inodeSecretDir.FileMode()
+	}
+
+	return z.inodeSecretField.FileMode()
+}
+
+// equal reports whether two version children have the same content and
+// attributes.
+func (z fsInodeSecretDirChild) equal(other fsInodeSecretDirChild) bool {
+	if z.inodeSecretDir != nil || other.inodeSecretDir != nil {
+		if z.inodeSecretDir == nil || other.inodeSecretDir == nil || len(z.inodeSecretDir.childs) != len(other.inodeSecretDir.childs) {
+			return false
+		}
+
+		for name, child := range z.inodeSecretDir.childs {
+			otherChild, ok := other.inodeSecretDir.childs[name]
+			if !ok || !child.equal(otherChild) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	var a, b fuse.Attr
+	z.fillAttr(&a)
+	other.fillAttr(&b)
+
+	z.inodeSecretField.lock.RLock()
+	defer z.inodeSecretField.lock.RUnlock()
+	other.inodeSecretField.lock.RLock()
+	defer other.inodeSecretField.lock.RUnlock()
+
+	return a.Mode == b.Mode && a.Owner == b.Owner && bytes.Equal(z.inodeSecretField.data, other.inodeSecretField.data)
+}
+
+func (z fsInodeSecretDirChild) fillAttr(out *fuse.Attr) {
+	if z.inodeSecretDir != nil {
+		z.inodeSecretDir.fillAttr(out)
+		return
+	}
+
+	z.inodeSecretField.lock.RLock()
+	out.Mode = z.inodeSecretField.AttrMode()
+	out.Owner = z.inodeSecretField.AttrOwner()
+	out.Size = uint64(len(z.inodeSecretField.data))
+	out.SetTimes(z.inodeSecretField.ATime, z.inodeSecretField.MTime(), z.inodeSecretField.CTime())
+	z.inodeSecretField.lock.RUnlock()
+}
+
+// FsInodeSecretDir is an immutable directory of a secret version snapshot,
+// so that readers resolving the fields through the same version directory
+// always get consistent values. It holds the fields and the subdirectories
+// of the nested values.
+type FsInodeSecretDir struct {
+	fs.Inode
+
+	optDockerVolume options.OptDockerVolume
+	secretData      backend.SecretData
+	xattrs          map[string][]byte
+
+	lock   sync.RWMutex
+	childs map[string]fsInodeSecretDirChild
+}
+
+func (*FsInodeSecretDir) FileMode() uint32 { return fuse.S_IFDIR }
+
+func (z *FsInodeSecretDir) AttrMode() uint32 { return z.optDockerVolume.MountMode }
+func (z *FsInodeSecretDir) AttrOwner() fuse.Owner {
+	return fuse.Owner{
+		Uid: uint32(z.optDockerVolume.MountUId),
+		Gid: uint32(z.optDockerVolume.MountGId),
+	}
+}
+
+func (z *FsInodeSecretDir) MTime() *time.Time { return z.secretData.CreatedAt() }
+func (z *FsInodeSecretDir) CTime() *time.Time { return z.MTime() }
+
+func newFsInodeSecretDir(optDockerVolume options.OptDockerVolume, secretData backend.SecretData, xattrs map[string][]byte) *FsInodeSecretDir {
+	util.Tracef("newFsInodeSecretDir(%+v)\n", optDockerVolume)
+
+	return &FsInodeSecretDir{
+		optDockerVolume: optDockerVolume,
+		secretData:      secretData,
+		xattrs:          xattrs,
+
+		lock:   sync.RWMutex{},
+		childs: map[string]fsInodeSecretDirChild{},
+	}
+}
+
+// forget releases the fields and subdirectories of a version which has been
+// replaced. The directories appear empty to the readers still referencing
+// them.
+func (z *FsInodeSecretDir) forget() {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	for _, v := range z.childs {
+		if v.inodeSecretDir != nil {
+			v.inodeSecretDir.forget()
+		}
+		v.inode.ForgetPersistent()
+	}
+	z.childs = map[string]fsInodeSecretDirChild{}
+}
+
+func (z *FsInodeSecretDir) fillAttr(out *fuse.Attr) {
+	out.Mode = z.AttrMode()
+	out.Owner = z.AttrOwner()
+	out.SetTimes(nil, z.MTime(), z.CTime())
+}
+
+var _ = (fs.NodeReaddirer)((*FsInodeSecretDir)(nil))
+var _ = (fs.NodeLookuper)((*FsInodeSecretDir)(nil))
+var _ = (fs.NodeGetattrer)((*FsInodeSecretDir)(nil))
+var _ = (fs.NodeGetxattrer)((*FsInodeSecretDir)(nil))
+var _ = (fs.NodeListxattrer)((*FsInodeSecretDir)(nil))
+
+func (z *FsInodeSecretDir) Readdir(ctx context.Context) (fs.DirStream, syscall.Errno) {
+	util.Tracef("FsInodeSecretDir[%v].Readdir()\n", z)
+
+	z.lock.RLock()
+	r := make([]fuse.DirEntry, 0, len(z.childs))
+	for _, k := range slices.Sorted(maps.Keys(z.childs)) {
+		r = append(r, fuse.DirEntry{Name: k, Mode: z.childs[k].FileMode()})
+	}
+	z.lock.RUnlock()
+
+	return fs.NewListDirStream(r), fs.OK
+}
+
+func (z *FsInodeSecretDir) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	util.Tracef("FsInodeSecretDir[%v].Lookup(%s)\n", z, name)
+
+	z.lock.RLock()
+	child, ok := z.childs[name]
+	z.lock.RUnlock()
+
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+
+	child.fillAttr(&out.Attr)
+
+	out.SetEntryTimeout(1 * time.Second)
+	out.SetAttrTimeout(1 * time.Second)
+
+	return child.inode, fs.OK
+}
+
+func (z *FsInodeSecretDir) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	util.Tracef("FsInodeSecretDir[%v].Getattr()\n", z)
+
+	z.fillAttr(&out.Attr)
+	out.SetTimeout(1 * time.Second)
+
+	return fs.OK
+}
+
+func (z *FsInodeSecretDir) Getxattr(ctx context.Context, attr string, dest []byte) (uint32, syscall.Errno) {
+	util.Tracef("FsInodeSecretDir[%v].Getxattr(%s)\n", z, attr)
+
+	return getxattr(z.xattrs, attr, dest)
+}
+
+func (z *FsInodeSecretDir) Listxattr(ctx context.Context, dest []byte) (uint32, syscall.Errno) {
+	util.Tracef("FsInodeSecretDir[%v].Listxattr()\n", z)
+
+	return listxattr(z.xattrs, dest)
+}
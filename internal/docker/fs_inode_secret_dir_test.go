@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"context"
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+func readdirNamesForTest(t *testing.T, node fs.NodeReaddirer) []string {
+	stream, errno := node.Readdir(context.Background())
+	if errno != fs.OK {
+		t.Fatalf("unexpected error: %v", errno)
+	}
+	defer stream.Close()
+
+	var r []string
+	for stream.HasNext() {
+		entry, errno := stream.Next()
+		if errno != fs.OK {
+			t.Fatalf("unexpected error: %v", errno)
+		}
+
+		r = append(r, entry.Name)
+	}
+
+	return r
+}
+
+func TestFsInodeSecretDir(t *testing.T) {
+	ctx := context.Background()
+	opt := options.MakeOptDockerVolume()
+
+	t.Run("nested fields are served by subdirectories", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		version := inodeSecret.newVersion(ctx, testSecretData{
+			uniqueId: "1",
+			values:   map[string]string{"user": "u", "tls/key": "K", "tls/ca/cert": "CA"},
+		})
+
+		if names := readdirNamesForTest(t, version.inodeSecretDir); !slices.Equal(names, []string{"tls", "user"}) {
+			t.Errorf("expected tls and user, got %v", names)
+		}
+
+		var out fuse.EntryOut
+		inode, errno := version.inodeSecretDir.Lookup(ctx, "tls", &out)
+		if errno != fs.OK {
+			t.Fatalf("unexpected error: %v", errno)
+		}
+
+		if inode.StableAttr().Mode != fuse.S_IFDIR {
+			t.Errorf("expected tls to be a directory, got mode %o", inode.StableAttr().Mode)
+		}
+
+		tlsDir, ok := inode.Operations().(*FsInodeSecretDir)
+		if !ok {
+			t.Fatalf("expected tls to be a secret directory, got %T", inode.Operations())
+		}
+
+		if names := readdirNamesForTest(t, tlsDir); !slices.Equal(names, []string{"ca", "key"}) {
+			t.Errorf("expected ca and key, got %v", names)
+		}
+
+		if value := fsSecretFieldDataForTest(t, tlsDir.childs["ca"].inodeSecretDir, "cert"); value != "CA" {
+			t.Errorf("expected tls/ca/cert to be CA, got %s", value)
+		}
+
+		if _, errno := tlsDir.Lookup(ctx, "cert", &out); errno != syscall.ENOENT {
+			t.Errorf("expected ENOENT for tls/cert, got %v", errno)
+		}
+	})
+
+	t.Run("field wins over the nested fields sharing its name", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		version := inodeSecret.newVersion(ctx, testSecretData{
+			uniqueId: "1",
+			values:   map[string]string{"tls": "T", "tls/key": "K"},
+		})
+
+		if value := fsSecretFieldDataForTest(t, version.inodeSecretDir, "tls"); value != "T" {
+			t.Errorf("expected tls to be T, got %s", value)
+		}
+	})
+
+	t.Run("field replaced by a directory", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		previousData := testSecretData{uniqueId: "1", values: map[string]string{"tls": "T"}}
+		inodeSecret.swapVersion(ctx, inodeSecret.newVersion(ctx, previousData), previousData)
+		fieldLink := inodeSecret.fieldLinks["tls"]
+
+		data := testSecretData{uniqueId: "2", values: map[string]string{"tls/key": "K"}}
+		version := inodeSecret.newVersion(ctx, data)
+		notifications := inodeSecret.swapVersion(ctx, version, data)
+
+		if inodeSecret.fieldLinks["tls"].inode != fieldLink.inode {
+			t.Error("expected the tls field symlink inode to be kept")
+		}
+
+		if !slices.Contains(notifications, fsNotification{inode: fieldLink.inode}) {
+			t.Errorf("expected the tls field symlink to be notified, got %+v", notifications)
+		}
+
+		if names := readdirNamesForTest(t, version.inodeSecretDir.childs["tls"].inodeSecretDir); !slices.Equal(names, []string{"key"}) {
+			t.Errorf("expected tls to be a directory of key, got %v", names)
+		}
+	})
+
+	t.Run("directory removed when its fields disappear", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		previousData := testSecretData{uniqueId: "1", values: map[string]string{"user": "u", "tls/key": "K"}}
+		previousVersion := inodeSecret.newVersion(ctx, previousData)
+		inodeSecret.swapVersion(ctx, previousVersion, previousData)
+		fieldLink := inodeSecret.fieldLinks["tls"]
+		tlsDir := previousVersion.inodeSecretDir.childs["tls"].inodeSecretDir
+
+		data := testSecretData{uniqueId: "2", values: map[string]string{"user": "u"}}
+		notifications := inodeSecret.swapVersion(ctx, inodeSecret.newVersion(ctx, data), data)
+
+		if _, ok := inodeSecret.fieldLinks["tls"]; ok {
+			t.Error("expected the tls field symlink to be removed")
+		}
+
+		if !slices.Contains(notifications, fsNotification{name: "tls", inode: fieldLink.inode}) {
+			t.Errorf("expected the tls removal to be notified, got %+v", notifications)
+		}
+
+		if names := readdirNamesForTest(t, tlsDir); len(names) != 0 {
+			t.Errorf("expected the previous tls directory to be emptied, got %v", names)
+		}
+	})
+}
@@ -25,6 +25,8 @@ type FsInodeSecretField struct {
 	fs.Inode
 
 	optDockerVolume options.OptDockerVolume
+	attr            backend.FieldAttr
+	xattrs          map[string][]byte
 
 	ATime *time.Time
 
@@ -35,12 +37,29 @@ type FsInodeSecretField struct {
 
 func (*FsInodeSecretField) FileMode() uint32 { return fuse.S_IFREG }
 
-func (z *FsInodeSecretField) AttrMode() uint32 { return z.optDockerVolume.FieldMountMode }
+func (z *FsInodeSecretField) AttrMode() uint32 {
+	if z.attr.Mode != nil {
+		return *z.attr.Mode
+	}
+
+	return z.optDockerVolume.FieldMountMode
+}
+
 func (z *FsInodeSecretField) AttrOwner() fuse.Owner {
-	return fuse.Owner{
+	r := fuse.Owner{
 		Uid: uint32(z.optDockerVolume.MountUId),
 		Gid: uint32(z.optDockerVolume.MountGId),
 	}
+
+	if z.attr.UId != nil {
+		r.Uid = uint32(*z.attr.UId)
+	}
+
+	if z.attr.GId != nil {
+		r.Gid = uint32(*z.attr.GId)
+	}
+
+	return r
 }
 
 func (z *FsInodeSecretField) MTime() *time.Time {
@@ -56,11 +75,13 @@ func (z *FsInodeSecretField) MTime() *time.Time {
 
 func (z *FsInodeSecretField) CTime() *time.Time { return z.MTime() }
 
-func newFsInodeSecretField(optDockerVolume options.OptDockerVolume) *FsInodeSecretField {
-	util.Tracef("newFsInodeSecretField(%v, %+v, %+v)\n", optDockerVolume)
+func newFsInodeSecretField(optDockerVolume options.OptDockerVolume, attr backend.FieldAttr, xattrs map[string][]byte) *FsInodeSecretField {
+	util.Tracef("newFsInodeSecretField(%+v, %+v)\n", optDockerVolume, attr)
 
 	return &FsInodeSecretField{
 		optDockerVolume: optDockerVolume,
+		attr:            attr,
+		xattrs:          xattrs,
 
 		lock: &sync.RWMutex{},
 	}
@@ -76,6 +97,8 @@ func (z *FsInodeSecretField) UpdateData(data []byte, secretData backend.SecretDa
 
 var _ = (fs.NodeOpener)((*FsInodeSecretField)(nil))
 var _ = (fs.NodeGetattrer)((*FsInodeSecretField)(nil))
+var _ = (fs.NodeGetxattrer)((*FsInodeSecretField)(nil))
+var _ = (fs.NodeListxattrer)((*FsInodeSecretField)(nil))
 var _ = (fs.FileReader)((*FsInodeSecretFieldFileHandle)(nil))
 
 func (z *FsInodeSecretField) Open(ctx context.Context, flags uint32) (fh fs.FileHandle, fuseFlags uint32, errno syscall.Errno) {
@@ -117,3 +140,15 @@ func (z *FsInodeSecretField) Getattr(ctx context.Context, fh fs.FileHandle, out
 
 	return fs.OK
 }
+
+func (z *FsInodeSecretField) Getxattr(ctx context.Context, attr string, dest []byte) (uint32, syscall.Errno) {
+	util.Tracef("FsInodeSecretField[%v].Getxattr(%s)\n", z, attr)
+
+	return getxattr(z.xattrs, attr, dest)
+}
+
+func (z *FsInodeSecretField) Listxattr(ctx context.Context, dest []byte) (uint32, syscall.Errno) {
+	util.Tracef("FsInodeSecretField[%v].Listxattr()\n", z)
+
+	return listxattr(z.xattrs, dest)
+}
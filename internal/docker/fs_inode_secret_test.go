@@ -0,0 +1,399 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/hanwen/go-fuse/v2/fs"
+)
+
+// newFsInodeSecretForTest returns a secret directory as the root of a
+// filesystem which is not mounted, so that its inodes can be created.
+func newFsInodeSecretForTest(secret backend.Secret, optDockerVolume options.OptDockerVolume) *FsInodeSecret {
+	r := NewFsInodeSecret(secret, optDockerVolume, "")
+
+	fs.NewNodeFS(r, &fs.Options{})
+
+	return r
+}
+
+func fsSecretLinkTargetForTest(t *testing.T, inodeSymlink *FsInodeSymlink) string {
+	target, errno := inodeSymlink.Readlink(context.Background())
+	if errno != fs.OK {
+		t.Fatalf("unexpected error: %v", errno)
+	}
+
+	return string(target)
+}
+
+func fsSecretFieldDataForTest(t *testing.T, inodeSecretDir *FsInodeSecretDir, name string) string {
+	child, ok := inodeSecretDir.childs[name]
+	if !ok || child.inodeSecretField == nil {
+		t.Fatalf("expected field %s, got %v", name, slices.Sorted(maps.Keys(inodeSecretDir.childs)))
+	}
+
+	child.inodeSecretField.lock.RLock()
+	defer child.inodeSecretField.lock.RUnlock()
+
+	return string(child.inodeSecretField.data)
+}
+
+func TestDiffFsSecretVersions(t *testing.T) {
+	ctx := context.Background()
+	opt := options.MakeOptDockerVolume()
+	inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+	previous := inodeSecret.newVersion(ctx, testSecretData{
+		uniqueId: "1",
+		values:   map[string]string{"a": "1", "b": "2", "c": "3", fsStatusDirName: "status"},
+	}).inodeSecretDir
+
+	t.Run("first version adds all the entries but the status directory", func(t *testing.T) {
+		r := diffFsSecretVersions(nil, previous)
+
+		if !slices.Equal(r.added, []string{"a", "b", "c"}) || len(r.changed) != 0 || len(r.removed) != 0 {
+			t.Errorf("expected a, b and c to be added, got %+v", r)
+		}
+	})
+
+	t.Run("entries are added, changed and removed", func(t *testing.T) {
+		next := inodeSecret.newVersion(ctx, testSecretData{
+			uniqueId: "2",
+			values:   map[string]string{"a": "1", "b": "20", "d": "4"},
+		}).inodeSecretDir
+
+		r := diffFsSecretVersions(previous, next)
+
+		if !slices.Equal(r.added, []string{"d"}) || !slices.Equal(r.changed, []string{"b"}) || !slices.Equal(r.removed, []string{"c"}) {
+			t.Errorf("expected d added, b changed and c removed, got %+v", r)
+		}
+	})
+
+	t.Run("attributes change is a change", func(t *testing.T) {
+		mode := uint32(0o400)
+
+		next := inodeSecret.newVersion(ctx, testSecretData{
+			uniqueId:   "2",
+			values:     map[string]string{"a": "1", "b": "2", "c": "3"},
+			fieldAttrs: map[string]backend.FieldAttr{"a": {Mode: &mode}},
+		}).inodeSecretDir
+
+		r := diffFsSecretVersions(previous, next)
+
+		if len(r.added) != 0 || !slices.Equal(r.changed, []string{"a"}) || len(r.removed) != 0 {
+			t.Errorf("expected a changed only, got %+v", r)
+		}
+	})
+}
+
+func TestFsInodeSecretSwapVersion(t *testing.T) {
+	ctx := context.Background()
+	opt := options.MakeOptDockerVolume()
+
+	swapVersion := func(inodeSecret *FsInodeSecret, data testSecretData) (*fsInodeSecretVersionChild, []fsNotification) {
+		version := inodeSecret.newVersion(ctx, data)
+
+		return version, inodeSecret.swapVersion(ctx, version, data)
+	}
+
+	t.Run("first version adds the field symlinks", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		version, notifications := swapVersion(inodeSecret, testSecretData{
+			uniqueId: "1",
+			values:   map[string]string{"a": "1", "b": "2"},
+		})
+
+		if target := fsSecretLinkTargetForTest(t, inodeSecret.dataLink.inodeSymlink); target != version.name {
+			t.Errorf("expected ..data to target %s, got %s", version.name, target)
+		}
+
+		if target := fsSecretLinkTargetForTest(t, inodeSecret.fieldLinks["a"].inodeSymlink); target != "..data/a" {
+			t.Errorf("expected a to target ..data/a, got %s", target)
+		}
+
+		expected := []fsNotification{{name: "a"}, {name: "b"}, {name: version.name}}
+		if !slices.Equal(notifications, expected) {
+			t.Errorf("expected %+v, got %+v", expected, notifications)
+		}
+	})
+
+	t.Run("next version adds, changes and removes the field symlinks", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		previousVersion, _ := swapVersion(inodeSecret, testSecretData{
+			uniqueId: "1",
+			values:   map[string]string{"a": "1", "b": "2", "c": "3"},
+		})
+		previousDataLink := inodeSecret.dataLink.inode
+		previousFieldLinks := maps.Clone(inodeSecret.fieldLinks)
+
+		version, notifications := swapVersion(inodeSecret, testSecretData{
+			uniqueId: "2",
+			values:   map[string]string{"a": "1", "b": "20", "d": "4"},
+		})
+
+		if inodeSecret.dataLink.inode != previousDataLink {
+			t.Error("expected the ..data symlink inode to be kept")
+		}
+
+		if target := fsSecretLinkTargetForTest(t, inodeSecret.dataLink.inodeSymlink); target != version.name {
+			t.Errorf("expected ..data to target %s, got %s", version.name, target)
+		}
+
+		if keys := slices.Sorted(maps.Keys(inodeSecret.fieldLinks)); !slices.Equal(keys, []string{"a", "b", "d"}) {
+			t.Errorf("expected the a, b and d field symlinks, got %v", keys)
+		}
+
+		if inodeSecret.fieldLinks["b"].inode != previousFieldLinks["b"].inode {
+			t.Error("expected the b field symlink inode to be kept")
+		}
+
+		if value := fsSecretFieldDataForTest(t, inodeSecret.version.inodeSecretDir, "b"); value != "20" {
+			t.Errorf("expected b to be 20, got %s", value)
+		}
+
+		if len(previousVersion.inodeSecretDir.childs) != 0 {
+			t.Error("expected the previous version to be forgotten")
+		}
+
+		expected := []fsNotification{
+			{inode: previousDataLink},
+			{name: "d"},
+			{inode: previousFieldLinks["b"].inode},
+			{name: "c", inode: previousFieldLinks["c"].inode},
+			{name: version.name},
+			{name: previousVersion.name, inode: previousVersion.inode},
+		}
+		if !slices.Equal(notifications, expected) {
+			t.Errorf("expected %+v, got %+v", expected, notifications)
+		}
+	})
+}
+
+func TestFsInodeSecretUpdateCache(t *testing.T) {
+	ctx := context.Background()
+	opt := options.MakeOptDockerVolume()
+
+	t.Run("notifications are sent in the versions order", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		sent := make(chan []fsNotification, 10)
+		release := make(chan struct{})
+		inodeSecret.notifySend = func(notifications []fsNotification) {
+			// the next version is queued while the first notifications are sent
+			<-release
+			sent <- notifications
+		}
+
+		inodeSecret.updateCache(ctx, testSecretData{uniqueId: "1", values: map[string]string{"a": "1", "b": "2"}})
+		firstVersion := *inodeSecret.version
+		dataLink := inodeSecret.dataLink.inode
+		fieldLinks := maps.Clone(inodeSecret.fieldLinks)
+
+		inodeSecret.updateCache(ctx, testSecretData{uniqueId: "2", values: map[string]string{"a": "10", "c": "3"}})
+		close(release)
+
+		expected := []fsNotification{
+			{name: "a"},
+			{name: "b"},
+			{name: firstVersion.name},
+
+			{inode: dataLink},
+			{name: "c"},
+			{inode: fieldLinks["a"].inode},
+			{name: "b", inode: fieldLinks["b"].inode},
+			{name: inodeSecret.version.name},
+			{name: firstVersion.name, inode: firstVersion.inode},
+		}
+
+		var notifications []fsNotification
+		timeout := time.After(5 * time.Second)
+
+		for len(notifications) < len(expected) {
+			select {
+			case n := <-sent:
+				notifications = append(notifications, n...)
+			case <-timeout:
+				t.Fatalf("expected %d notifications, got %+v", len(expected), notifications)
+			}
+		}
+
+		if !slices.Equal(notifications, expected) {
+			t.Errorf("expected %+v, got %+v", expected, notifications)
+		}
+	})
+
+	t.Run("same data is not notified", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		sent := make(chan []fsNotification, 10)
+		inodeSecret.notifySend = func(notifications []fsNotification) { sent <- notifications }
+
+		data := testSecretData{uniqueId: "1", values: map[string]string{"a": "1"}}
+		inodeSecret.updateCache(ctx, data)
+		<-sent
+
+		version := inodeSecret.version
+		inodeSecret.updateCache(ctx, data)
+
+		if inodeSecret.version != version {
+			t.Error("expected the version to be kept")
+		}
+
+		select {
+		case n := <-sent:
+			t.Errorf("expected no notification, got %+v", n)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
+
+func TestFsInodeSecretRefresh(t *testing.T) {
+	opt := options.MakeOptDockerVolume()
+	opt.RefreshInterval = 60
+	opt.RefreshJitter = 10
+
+	// newRefreshedFsInodeSecret returns a secret directory which refresh delays
+	// are sent to the returned channel, the next refresh being triggered by
+	// sending to the other one.
+	newRefreshedFsInodeSecret := func(secret *testSecret) (*FsInodeSecret, <-chan time.Duration, chan<- time.Time) {
+		delays := make(chan time.Duration)
+		ticks := make(chan time.Time)
+
+		r := newFsInodeSecretForTest(secret, opt)
+		r.notifySend = func([]fsNotification) {}
+		r.refreshAfter = func(d time.Duration) <-chan time.Time {
+			delays <- d
+			return ticks
+		}
+
+		return r, delays, ticks
+	}
+
+	t.Run("delay is the interval plus a random jitter", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(nil, opt)
+
+		for range 100 {
+			if d := inodeSecret.refreshDelay(); d < 60*time.Second || d >= 70*time.Second {
+				t.Fatalf("expected a delay between 60s and 70s, got %v", d)
+			}
+		}
+
+		withoutJitter := opt
+		withoutJitter.RefreshJitter = 0
+
+		if d := newFsInodeSecretForTest(nil, withoutJitter).refreshDelay(); d != 60*time.Second {
+			t.Errorf("expected a 60s delay, got %v", d)
+		}
+	})
+
+	t.Run("secret is refreshed after each delay until closed", func(t *testing.T) {
+		secret := &testSecret{data: testSecretData{uniqueId: "1", values: map[string]string{"a": "1"}}}
+		inodeSecret, delays, ticks := newRefreshedFsInodeSecret(secret)
+
+		inodeSecret.StartRefresh()
+
+		for i := 1; i <= 3; i++ {
+			if i > 1 {
+				ticks <- time.Now()
+			}
+
+			if d := <-delays; d < 60*time.Second || d >= 70*time.Second {
+				t.Errorf("expected a delay between 60s and 70s, got %v", d)
+			}
+
+			if secret.getDataCount != i {
+				t.Fatalf("expected %d requests, got %d", i, secret.getDataCount)
+			}
+		}
+
+		inodeSecret.Close()
+
+		if secret.getDataCount != 3 {
+			t.Errorf("expected no request once closed, got %d", secret.getDataCount)
+		}
+
+		if inodeSecret.secretData != nil || inodeSecret.version != nil {
+			t.Error("expected the cache to be cleared once closed")
+		}
+	})
+
+	t.Run("failed refresh keeps the previous version", func(t *testing.T) {
+		secret := &testSecret{data: testSecretData{uniqueId: "1", values: map[string]string{"a": "1"}}}
+		inodeSecret, delays, ticks := newRefreshedFsInodeSecret(secret)
+
+		inodeSecret.StartRefresh()
+		defer inodeSecret.Close()
+
+		<-delays
+		version := inodeSecret.version
+
+		secret.err = errors.New("connection refused")
+		ticks <- time.Now()
+		<-delays
+
+		if inodeSecret.version != version || inodeSecret.secretData.UniqueId() != "1" {
+			t.Error("expected the previous version to be kept")
+		}
+
+		if _, ok := inodeSecret.fieldLinks["a"]; !ok {
+			t.Error("expected the a field symlink to be kept")
+		}
+
+		secret.err = fmt.Errorf("get secret: %w", os.ErrNotExist)
+		ticks <- time.Now()
+		<-delays
+
+		if inodeSecret.version != nil || len(inodeSecret.fieldLinks) != 0 {
+			t.Error("expected the cache to be cleared once the secret no longer exists")
+		}
+	})
+
+	t.Run("volume unmount stops the refresh", func(t *testing.T) {
+		secret := &testSecret{data: testSecretData{uniqueId: "1"}}
+		inodeSecret, delays, _ := newRefreshedFsInodeSecret(secret)
+
+		volumeFs := newFs(FsConfig{})
+		fs.NewNodeFS(volumeFs.InodeRoot, &fs.Options{})
+
+		volume, _ := newVolume(VolumeConfig{Name: "vol"})
+		if err := volumeFs.InodeRoot.addInodeSecret(volume.Name, inodeSecret); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		volume.fsInodeSecret = inodeSecret
+		volume.secret = secret
+		volume.mountRequestIds["request"] = true
+
+		inodeSecret.StartRefresh()
+		refreshDone := inodeSecret.refreshDone
+		<-delays
+
+		if err := volume.unmount(volumeFs, "request"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		select {
+		case <-refreshDone:
+		default:
+			t.Error("expected the refresh to be stopped")
+		}
+
+		if secret.getDataCount != 1 {
+			t.Errorf("expected 1 request, got %d", secret.getDataCount)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"syscall"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+const (
+	fsStatusDirName = ".vaultfs"
+
+	fsStatusFileNameStatus         = "status.json"
+	fsStatusFileNameVersion        = "version"
+	fsStatusFileNameLeaseExpiresAt = "lease_expires_at"
+	fsStatusFileNameLastError      = "last_error"
+)
+
+var fsStatusFileNames = []string{
+	fsStatusFileNameStatus,
+	fsStatusFileNameVersion,
+	fsStatusFileNameLeaseExpiresAt,
+	fsStatusFileNameLastError,
+}
+
+type fsStatus struct {
+	FetchedAt      *time.Time `json:"fetched_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+	Version        *string    `json:"version"`
+	Stale          bool       `json:"stale"`
+	LastError      *string    `json:"last_error"`
+	LastErrorAt    *time.Time `json:"last_error_at"`
+}
+
+type fsInodeStatusChild struct {
+	inodeStatus *FsInodeStatus
+	inode       *fs.Inode
+}
+
+// FsInodeStatus is the read-only status directory of a secret directory, its
+// files being generated from the secret cache state.
+type FsInodeStatus struct {
+	fs.Inode
+
+	inodeSecret *FsInodeSecret
+}
+
+func (*FsInodeStatus) FileMode() uint32 { return fuse.S_IFDIR }
+
+func (z *FsInodeStatus) AttrMode() uint32      { return z.inodeSecret.optDockerVolume.MountMode }
+func (z *FsInodeStatus) AttrOwner() fuse.Owner { return z.inodeSecret.AttrOwner() }
+
+func newFsInodeStatus(inodeSecret *FsInodeSecret) *FsInodeStatus {
+	util.Tracef("newFsInodeStatus()\n")
+
+	return &FsInodeStatus{
+		inodeSecret: inodeSecret,
+	}
+}
+
+// forget releases the status directory and its files.
+func (z *FsInodeStatus) forget() {
+	for _, child := range z.Children() {
+		child.ForgetPersistent()
+	}
+}
+
+func (z *FsInodeStatus) fillAttr(out *fuse.Attr) {
+	out.Mode = z.AttrMode()
+	out.Owner = z.AttrOwner()
+	out.SetTimes(nil, z.inodeSecret.MTime(), z.inodeSecret.CTime())
+}
+
+var _ = (fs.NodeOnAdder)((*FsInodeStatus)(nil))
+var _ = (fs.NodeGetattrer)((*FsInodeStatus)(nil))
+
+func (z *FsInodeStatus) OnAdd(ctx context.Context) {
+	util.Tracef("FsInodeStatus[%v].OnAdd()\n", z)
+
+	for _, name := range fsStatusFileNames {
+		inodeStatusFile := &FsInodeStatusFile{
+			inodeSecret: z.inodeSecret,
+			name:        name,
+		}
+
+		z.AddChild(name, z.NewPersistentInode(ctx, inodeStatusFile, fs.StableAttr{Mode: inodeStatusFile.FileMode()}), false)
+	}
+}
+
+func (z *FsInodeStatus) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	util.Tracef("FsInodeStatus[%v].Getattr()\n", z)
+
+	z.fillAttr(&out.Attr)
+	out.SetTimeout(1 * time.Second)
+
+	return fs.OK
+}
+
+// FsInodeStatusFile is a file of the status directory, rendered when opened.
+type FsInodeStatusFile struct {
+	fs.Inode
+
+	inodeSecret *FsInodeSecret
+	name        string
+}
+
+func (*FsInodeStatusFile) FileMode() uint32 { return fuse.S_IFREG }
+
+func (z *FsInodeStatusFile) AttrMode() uint32      { return z.inodeSecret.optDockerVolume.FieldMountMode }
+func (z *FsInodeStatusFile) AttrOwner() fuse.Owner { return z.inodeSecret.AttrOwner() }
+
+var _ = (fs.NodeOpener)((*FsInodeStatusFile)(nil))
+var _ = (fs.NodeGetattrer)((*FsInodeStatusFile)(nil))
+
+func (z *FsInodeStatusFile) Open(ctx context.Context, flags uint32) (fh fs.FileHandle, fuseFlags uint32, errno syscall.Errno) {
+	util.Tracef("FsInodeStatusFile[%v].Open(%v)\n", z, flags)
+
+	// the status reflects the loaded secret data, the loading error being
+	// reported rather than returned
+	z.inodeSecret.loadData(ctx, false)
+
+	// the content size may differ from the one previously reported
+	return &FsInodeSecretFieldFileHandle{data: z.render()}, fuse.FOPEN_DIRECT_IO, fs.OK
+}
+
+func (z *FsInodeStatusFile) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	util.Tracef("FsInodeStatusFile[%v].Getattr(%+v)\n", z, fh)
+
+	out.Mode = z.AttrMode()
+	out.Owner = z.AttrOwner()
+	out.SetTimes(nil, z.inodeSecret.MTime(), z.inodeSecret.CTime())
+	out.Attr.Size = uint64(len(z.render()))
+	out.SetTimeout(1 * time.Second)
+
+	return fs.OK
+}
+
+func (z *FsInodeStatusFile) render() []byte {
+	status := z.inodeSecret.status()
+
+	switch z.name {
+	case fsStatusFileNameStatus:
+		content, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			util.Errorf("Unable to render %s: %v\n", z.name, err)
+			return nil
+		}
+
+		return append(content, '\n')
+
+	case fsStatusFileNameVersion:
+		return statusFileLine(status.Version)
+
+	case fsStatusFileNameLeaseExpiresAt:
+		if status.LeaseExpiresAt == nil {
+			return nil
+		}
+
+		return []byte(status.LeaseExpiresAt.Format(time.RFC3339) + "\n")
+
+	case fsStatusFileNameLastError:
+		return statusFileLine(status.LastError)
+	}
+
+	return nil
+}
+
+// statusFileLine returns a value as a line, or an empty content when unknown.
+func statusFileLine(v *string) []byte {
+	if v == nil {
+		return nil
+	}
+
+	return []byte(*v + "\n")
+}
+
+// status returns the state of the secret cache.
+func (z *FsInodeSecret) status() fsStatus {
+	secretStatus := z.secret.Status()
+
+	r := fsStatus{
+		FetchedAt:      utcTime(secretStatus.FetchedAt),
+		ExpiresAt:      utcTime(secretStatus.ExpiresAt),
+		LeaseExpiresAt: utcTime(secretStatus.LeaseExpiresAt),
+		LastErrorAt:    utcTime(secretStatus.LastErrorAt),
+		Version:        secretStatus.Version,
+	}
+
+	if secretStatus.LastError != nil {
+		lastError := secretStatus.LastError.Error()
+		r.LastError = &lastError
+	}
+
+	// the data could not be refreshed, or the credentials are no longer valid
+	r.Stale = r.FetchedAt == nil || r.LastError != nil || (r.LeaseExpiresAt != nil && time.Now().After(*r.LeaseExpiresAt))
+
+	return r
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	r := t.UTC()
+	return &r
+}
@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+func renderStatusFilesForTest(inodeSecret *FsInodeSecret) map[string]string {
+	r := map[string]string{}
+
+	for _, name := range fsStatusFileNames {
+		r[name] = string((&FsInodeStatusFile{inodeSecret: inodeSecret, name: name}).render())
+	}
+
+	return r
+}
+
+func TestFsInodeStatusFile(t *testing.T) {
+	opt := options.MakeOptDockerVolume()
+
+	t.Run("files render the secret status", func(t *testing.T) {
+		zone := time.FixedZone("CET", 3600)
+		fetchedAt := time.Date(2026, 1, 1, 1, 0, 0, 0, zone)
+		expiresAt := fetchedAt.Add(time.Hour)
+		leaseExpiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Second).In(zone)
+		version := "app=3,db=1"
+
+		inodeSecret := newFsInodeSecretForTest(&testSecret{status: backend.SecretStatus{
+			FetchedAt:      &fetchedAt,
+			ExpiresAt:      &expiresAt,
+			LeaseExpiresAt: &leaseExpiresAt,
+			Version:        &version,
+		}}, opt)
+
+		leaseExpiresAtText := leaseExpiresAt.UTC().Format(time.RFC3339)
+
+		expected := map[string]string{
+			fsStatusFileNameStatus: "{\n" +
+				"  \"fetched_at\": \"2026-01-01T00:00:00Z\",\n" +
+				"  \"expires_at\": \"2026-01-01T01:00:00Z\",\n" +
+				"  \"lease_expires_at\": \"" + leaseExpiresAtText + "\",\n" +
+				"  \"version\": \"app=3,db=1\",\n" +
+				"  \"stale\": false,\n" +
+				"  \"last_error\": null,\n" +
+				"  \"last_error_at\": null\n" +
+				"}\n",
+			fsStatusFileNameVersion:        "app=3,db=1\n",
+			fsStatusFileNameLeaseExpiresAt: leaseExpiresAtText + "\n",
+			fsStatusFileNameLastError:      "",
+		}
+
+		for name, content := range renderStatusFilesForTest(inodeSecret) {
+			if content != expected[name] {
+				t.Errorf("%s: expected %q, got %q", name, expected[name], content)
+			}
+		}
+	})
+
+	t.Run("unknown values render null or empty files", func(t *testing.T) {
+		inodeSecret := newFsInodeSecretForTest(&testSecret{}, opt)
+
+		expected := map[string]string{
+			fsStatusFileNameStatus: "{\n" +
+				"  \"fetched_at\": null,\n" +
+				"  \"expires_at\": null,\n" +
+				"  \"lease_expires_at\": null,\n" +
+				"  \"version\": null,\n" +
+				"  \"stale\": true,\n" +
+				"  \"last_error\": null,\n" +
+				"  \"last_error_at\": null\n" +
+				"}\n",
+			fsStatusFileNameVersion:        "",
+			fsStatusFileNameLeaseExpiresAt: "",
+			fsStatusFileNameLastError:      "",
+		}
+
+		for name, content := range renderStatusFilesForTest(inodeSecret) {
+			if content != expected[name] {
+				t.Errorf("%s: expected %q, got %q", name, expected[name], content)
+			}
+		}
+	})
+
+	t.Run("failed request renders the error", func(t *testing.T) {
+		fetchedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		lastErrorAt := fetchedAt.Add(time.Minute)
+
+		inodeSecret := newFsInodeSecretForTest(&testSecret{status: backend.SecretStatus{
+			FetchedAt:   &fetchedAt,
+			LastError:   errors.New("lease renewal: permission denied"),
+			LastErrorAt: &lastErrorAt,
+		}}, opt)
+
+		r := renderStatusFilesForTest(inodeSecret)
+
+		if r[fsStatusFileNameLastError] != "lease renewal: permission denied\n" {
+			t.Errorf("expected the last error, got %q", r[fsStatusFileNameLastError])
+		}
+
+		status := inodeSecret.status()
+		if !status.Stale || status.LastErrorAt == nil || !status.LastErrorAt.Equal(lastErrorAt) {
+			t.Errorf("expected a stale status with the last error time, got %+v", status)
+		}
+	})
+
+	t.Run("expired lease is stale", func(t *testing.T) {
+		fetchedAt := time.Now().Add(-time.Hour)
+		leaseExpiresAt := time.Now().Add(-time.Minute)
+
+		inodeSecret := newFsInodeSecretForTest(&testSecret{status: backend.SecretStatus{
+			FetchedAt:      &fetchedAt,
+			LeaseExpiresAt: &leaseExpiresAt,
+		}}, opt)
+
+		if !inodeSecret.status().Stale {
+			t.Error("expected an expired lease to be stale")
+		}
+	})
+}
+
+func TestFsInodeSecretStatusDir(t *testing.T) {
+	ctx := context.Background()
+
+	opt := options.MakeOptDockerVolume()
+	opt.RefreshInterval = 60
+
+	data := testSecretData{uniqueId: "1", values: map[string]string{"password": "secret"}}
+
+	inodeSecret := newFsInodeSecretForTest(&testSecret{data: data}, opt)
+	version := inodeSecret.newVersion(ctx, data)
+	inodeSecret.swapVersion(ctx, version, data)
+
+	t.Run("status directory is hidden from readdir", func(t *testing.T) {
+		names := readdirNamesForTest(t, inodeSecret)
+
+		if expected := []string{fsSecretDataLinkName, version.name, "password"}; !slices.Equal(names, expected) {
+			t.Errorf("expected %v, got %v", expected, names)
+		}
+	})
+
+	t.Run("status directory is found by lookup", func(t *testing.T) {
+		var out fuse.EntryOut
+		inode, errno := inodeSecret.Lookup(ctx, fsStatusDirName, &out)
+		if errno != fs.OK {
+			t.Fatalf("unexpected error: %v", errno)
+		}
+
+		var names []string
+		for name := range inode.Children() {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		if expected := slices.Sorted(slices.Values(fsStatusFileNames)); !slices.Equal(names, expected) {
+			t.Errorf("expected %v, got %v", expected, names)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+)
+
+const fsSymlinkMode = 0o777
+
+type fsInodeSymlinkChild struct {
+	inodeSymlink *FsInodeSymlink
+	inode        *fs.Inode
+}
+
+type FsInodeSymlink struct {
+	fs.Inode
+
+	optDockerVolume options.OptDockerVolume
+
+	lock   *sync.RWMutex
+	target []byte
+	mTime  *time.Time
+}
+
+func (*FsInodeSymlink) FileMode() uint32 { return fuse.S_IFLNK }
+
+func (z *FsInodeSymlink) AttrMode() uint32 { return fsSymlinkMode }
+func (z *FsInodeSymlink) AttrOwner() fuse.Owner {
+	return fuse.Owner{
+		Uid: uint32(z.optDockerVolume.MountUId),
+		Gid: uint32(z.optDockerVolume.MountGId),
+	}
+}
+
+func newFsInodeSymlink(optDockerVolume options.OptDockerVolume, target string, mTime *time.Time) *FsInodeSymlink {
+	util.Tracef("newFsInodeSymlink(%+v, %s)\n", optDockerVolume, target)
+
+	return &FsInodeSymlink{
+		optDockerVolume: optDockerVolume,
+
+		lock:   &sync.RWMutex{},
+		target: []byte(target),
+		mTime:  mTime,
+	}
+}
+
+// SetTarget replaces the symlink target in a single step.
+func (z *FsInodeSymlink) SetTarget(target string, mTime *time.Time) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	z.target = []byte(target)
+	z.mTime = mTime
+}
+
+func (z *FsInodeSymlink) fillAttr(out *fuse.Attr) {
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+
+	out.Mode = z.AttrMode()
+	out.Owner = z.AttrOwner()
+	out.Size = uint64(len(z.target))
+	out.SetTimes(nil, z.mTime, z.mTime)
+}
+
+var _ = (fs.NodeReadlinker)((*FsInodeSymlink)(nil))
+var _ = (fs.NodeGetattrer)((*FsInodeSymlink)(nil))
+
+func (z *FsInodeSymlink) Readlink(ctx context.Context) ([]byte, syscall.Errno) {
+	util.Tracef("FsInodeSymlink[%v].Readlink()\n", z)
+
+	z.lock.RLock()
+	defer z.lock.RUnlock()
+
+	target := make([]byte, len(z.target))
+	copy(target, z.target)
+
+	return target, fs.OK
+}
+
+func (z *FsInodeSymlink) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	util.Tracef("FsInodeSymlink[%v].Getattr()\n", z)
+
+	z.fillAttr(&out.Attr)
+	out.SetTimeout(1 * time.Second)
+
+	return fs.OK
+}
@@ -33,10 +33,11 @@ type PluginConfig struct {
 	UnixSocketGId  uint16
 	UnixSocketMode uint32
 
-	VolumeDriverDisabled      bool
-	VolumeDriverFsConfig      FsConfig
-	VolumeDriverGlobalScope   bool
-	VolumeDriverStateFilePath string
+	VolumeDriverDisabled        bool
+	VolumeDriverFsConfig        FsConfig
+	VolumeDriverGlobalScope     bool
+	VolumeDriverStateFilePath   string
+	VolumeDriverStateEncryption VolumeDriverStateEncryptionConfig
 
 	SecretProviderDisabled bool
 
@@ -54,8 +55,9 @@ func NewPlugin(config PluginConfig) (*Plugin, error) {
 			VolumeDriverConfig{
 				FsConfig: config.VolumeDriverFsConfig,
 
-				GlobalScope:   config.VolumeDriverGlobalScope,
-				StateFilePath: config.VolumeDriverStateFilePath,
+				GlobalScope:     config.VolumeDriverGlobalScope,
+				StateFilePath:   config.VolumeDriverStateFilePath,
+				StateEncryption: config.VolumeDriverStateEncryption,
 
 				DefaultOptDocker: config.DefaultOptDocker,
 			},
@@ -20,6 +20,16 @@ func newSecret(config SecretConfig) (*backend.Secret, error) {
 	var secret backend.Secret
 	var err error
 
+	if config.OptSecret.Sources != nil {
+		sourcesSecret, err := newSourcesSecret(config.OptSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		secret = sourcesSecret
+		return &secret, nil
+	}
+
 	switch config.OptSecret.Backend {
 	case options.SecretBackendVault:
 		secret, err = backendVault.NewVaultSecret(backendVault.VaultSecretConfig{
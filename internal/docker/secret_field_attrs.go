@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+)
+
+// volumeFileAttr returns the owner, group and mode overrides of a volume file.
+// The volume options by file name take precedence over the ones by field name,
+// themselves taking precedence over the ones declared by the secret. The
+// rendered files (templates and aggregate formats) have no field, only the
+// volume options by file name apply to them.
+func volumeFileAttr(opt options.OptDockerVolume, data backend.SecretData, name string, field string) backend.FieldAttr {
+	var r backend.FieldAttr
+	if field != "" {
+		r = data.FieldAttrs()[field]
+	}
+
+	for _, k := range []string{field, name} {
+		if uid, ok := opt.FieldUIds[k]; ok {
+			r.UId = &uid
+		}
+
+		if gid, ok := opt.FieldGIds[k]; ok {
+			r.GId = &gid
+		}
+
+		if mode, ok := opt.FieldModes[k]; ok {
+			r.Mode = &mode
+		}
+	}
+
+	return r
+}
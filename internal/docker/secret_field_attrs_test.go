@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+)
+
+type testSecretData struct {
+	uniqueId   string
+	createdAt  *time.Time
+	values     map[string]string
+	metadata   map[string]string
+	transforms map[string][]string
+	fieldAttrs map[string]backend.FieldAttr
+}
+
+func (z testSecretData) UniqueId() string      { return z.uniqueId }
+func (z testSecretData) CreatedAt() *time.Time { return z.createdAt }
+func (z testSecretData) GetKeys() []string     { return slices.Sorted(maps.Keys(z.values)) }
+func (z testSecretData) GetValue(key string) (*string, bool) {
+	value, ok := z.values[key]
+	if !ok {
+		return nil, false
+	}
+
+	return &value, true
+}
+func (z testSecretData) Metadata() map[string]string              { return z.metadata }
+func (z testSecretData) FieldTransforms() map[string][]string     { return z.transforms }
+func (z testSecretData) FieldAttrs() map[string]backend.FieldAttr { return z.fieldAttrs }
+
+func TestVolumeFileAttr(t *testing.T) {
+	secretUId := uint16(10)
+	secretMode := uint32(0o444)
+
+	data := testSecretData{
+		fieldAttrs: map[string]backend.FieldAttr{
+			"password": {UId: &secretUId, Mode: &secretMode},
+		},
+	}
+
+	t.Run("secret declarations apply to the field files", func(t *testing.T) {
+		r := volumeFileAttr(options.MakeOptDockerVolume(), data, "password", "password")
+
+		if r.UId == nil || *r.UId != 10 || r.Mode == nil || *r.Mode != 0o444 || r.GId != nil {
+			t.Errorf("expected the secret declarations, got %+v", r)
+		}
+	})
+
+	t.Run("volume options by field name override the secret declarations", func(t *testing.T) {
+		opt := options.MakeOptDockerVolume()
+		opt.FieldUIds = map[string]uint16{"password": 20}
+
+		r := volumeFileAttr(opt, data, "db_password", "password")
+
+		if r.UId == nil || *r.UId != 20 || r.Mode == nil || *r.Mode != 0o444 {
+			t.Errorf("expected uid 20 and the secret mode, got %+v", r)
+		}
+	})
+
+	t.Run("volume options by file name override the ones by field name", func(t *testing.T) {
+		opt := options.MakeOptDockerVolume()
+		opt.FieldUIds = map[string]uint16{"password": 20, "db_password": 30}
+		opt.FieldModes = map[string]uint32{"password": 0o400, "db_password": 0o440}
+
+		r := volumeFileAttr(opt, data, "db_password", "password")
+
+		if r.UId == nil || *r.UId != 30 || r.Mode == nil || *r.Mode != 0o440 {
+			t.Errorf("expected uid 30 and mode 0440, got %+v", r)
+		}
+	})
+
+	t.Run("volume options by file name apply to the rendered files", func(t *testing.T) {
+		opt := options.MakeOptDockerVolume()
+		opt.FieldGIds = map[string]uint16{"secret.json": 40, "password": 50}
+
+		r := volumeFileAttr(opt, data, "secret.json", "")
+
+		if r.GId == nil || *r.GId != 40 || r.UId != nil || r.Mode != nil {
+			t.Errorf("expected gid 40 only, got %+v", r)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"encoding/json"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
+// volumeFormatFileBaseName is the base name of the aggregate files, the
+// format being the extension (eg. secret.json).
+const volumeFormatFileBaseName = "secret"
+
+// renderVolumeFormats renders the secret fields (the metadata left out),
+// filtered and renamed as the volume files, in each of the volume aggregate
+// formats.
+func renderVolumeFormats(optDockerVolume options.OptDockerVolume, data backend.SecretData) map[string][]byte {
+	r := map[string][]byte{}
+
+	if len(optDockerVolume.Formats) == 0 {
+		return r
+	}
+
+	fields := map[string]string{}
+	for field, value := range secretDataFields(data) {
+		for _, name := range optDockerVolume.FieldNames(field) {
+			fields[name] = value
+		}
+	}
+
+	for _, format := range optDockerVolume.Formats {
+		var content []byte
+
+		switch format {
+		case options.DockerVolumeFormatJson:
+			var err error
+			content, err = json.MarshalIndent(fields, "", "  ")
+			if err != nil {
+				util.Errorf("Unable to render %s format: %v\n", format, err)
+				continue
+			}
+			content = append(content, '\n')
+
+		case options.DockerVolumeFormatEnv:
+			content = util.EncodeDotEnv(fields)
+
+		case options.DockerVolumeFormatYaml:
+			content = util.EncodeYaml(fields)
+
+		case options.DockerVolumeFormatProperties:
+			content = util.EncodeProperties(fields)
+
+		default:
+			continue
+		}
+
+		r[volumeFormatFileBaseName+"."+format] = content
+	}
+
+	return r
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+)
+
+func TestRenderVolumeFormats(t *testing.T) {
+	data := testSecretData{
+		values: map[string]string{
+			" spaced": " value",
+			"control": "tab\there\x01\x7f\u0086\u2028",
+			"emoji":   "😀",
+			"null":    "~",
+			"yes":     "true",
+		},
+		metadata: map[string]string{".version": "3"},
+	}
+
+	opt := options.MakeOptDockerVolume()
+	opt.Formats = []string{
+		options.DockerVolumeFormatJson,
+		options.DockerVolumeFormatEnv,
+		options.DockerVolumeFormatYaml,
+		options.DockerVolumeFormatProperties,
+	}
+
+	r := renderVolumeFormats(opt, data)
+
+	if names := slices.Sorted(maps.Keys(r)); !slices.Equal(names, []string{"secret.env", "secret.json", "secret.properties", "secret.yaml"}) {
+		t.Fatalf("expected a file by format, got %v", names)
+	}
+
+	for name, expected := range map[string]string{
+		"secret.json": "{\n" +
+			"  \" spaced\": \" value\",\n" +
+			"  \"control\": \"tab\\there\\u0001\x7f\u0086\\u2028\",\n" +
+			"  \"emoji\": \"😀\",\n" +
+			"  \"null\": \"~\",\n" +
+			"  \"yes\": \"true\"\n" +
+			"}\n",
+		"secret.env": "_spaced=' value'\n" +
+			"control='tab\there\x01\x7f\u0086\u2028'\n" +
+			"emoji='😀'\n" +
+			"null='~'\n" +
+			"yes='true'\n",
+		"secret.yaml": "\" spaced\": \" value\"\n" +
+			"\"control\": \"tab\\there\\x01\\x7f\\x86\\u2028\"\n" +
+			"\"emoji\": \"😀\"\n" +
+			"\"null\": \"~\"\n" +
+			"\"yes\": \"true\"\n",
+		"secret.properties": "\\ spaced=\\ value\n" +
+			"control=tab\\there\\u0001\\u007f\\u0086\\u2028\n" +
+			"emoji=\\ud83d\\ude00\n" +
+			"null=~\n" +
+			"yes=true\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if string(r[name]) != expected {
+				t.Errorf("expected %q, got %q", expected, r[name])
+			}
+		})
+	}
+}
@@ -5,8 +5,12 @@
 package docker
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
 	dockerSdkPlugin "github.com/anthochamp/docker-plugin-vaultfs/internal/dockersdk/plugin"
 	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
 	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
@@ -50,13 +54,51 @@ func (z SecretProvider) GetSecret(r dockerSdkPlugin.SecretProviderGetSecretReque
 		return nil, fmt.Errorf("get secret data: %w", err)
 	}
 
-	value, ok := (*data).GetValue(r.SecretName)
-	if !ok {
-		return nil, fmt.Errorf("get secret data field %s: %w", r.SecretName, err)
+	value, err := secretProviderValue(r.SecretName, optDocker.DockerSecret, *data)
+	if err != nil {
+		return nil, err
 	}
 
 	return &dockerSdkPlugin.SecretProviderGetSecretResponse{
 		DoNotReuse: true,
-		Value:      []byte(*value),
+		Value:      value,
 	}, nil
 }
+
+// secretProviderValue computes the Docker secret value from the secret data
+// according to the Docker secret format option.
+func secretProviderValue(secretName string, optDockerSecret options.OptDockerSecret, data backend.SecretData) ([]byte, error) {
+	switch optDockerSecret.Format {
+	case options.DockerSecretFormatField:
+		field := optDockerSecret.EffectiveField(secretName)
+
+		if value, ok := data.GetValue(field); ok {
+			return []byte(*value), nil
+		}
+
+		// the metadata remain selectable by their dotfile name
+		if value, ok := data.Metadata()[field]; ok {
+			return []byte(value), nil
+		}
+
+		return nil, fmt.Errorf("get secret data field %s: %w", field, os.ErrNotExist)
+
+	case options.DockerSecretFormatJson:
+		value, err := json.Marshal(secretDataFields(data))
+		if err != nil {
+			return nil, fmt.Errorf("serialize secret data: %w", err)
+		}
+
+		return value, nil
+
+	case options.DockerSecretFormatTemplate:
+		value, err := renderSecretTemplate(secretName, *optDockerSecret.Template, data)
+		if err != nil {
+			return nil, fmt.Errorf("render secret template: %w", err)
+		}
+
+		return value, nil
+	}
+
+	return nil, errors.New("not implemented")
+}
@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
+// SourcesSecret composes the secrets of several sources, each one keeping its
+// own cache and lease lifecycle.
+type SourcesSecret struct {
+	optSecret options.OptSecret
+	secrets   []backend.Secret
+}
+
+type sourcesSecretData struct {
+	uniqueId        string
+	createdAt       *time.Time
+	data            map[string]string
+	metadata        map[string]string
+	fieldTransforms map[string][]string
+	fieldAttrs      map[string]backend.FieldAttr
+}
+
+func (z sourcesSecretData) UniqueId() string { return z.uniqueId }
+
+func (z sourcesSecretData) CreatedAt() *time.Time { return z.createdAt }
+
+func (z sourcesSecretData) GetKeys() []string {
+	r := make([]string, 0, len(z.data))
+	for k := range z.data {
+		r = append(r, k)
+	}
+	return r
+}
+
+func (z sourcesSecretData) GetValue(key string) (*string, bool) {
+	value, ok := z.data[key]
+
+	if !ok {
+		return nil, false
+	}
+
+	return &value, true
+}
+
+func (z sourcesSecretData) Metadata() map[string]string { return z.metadata }
+
+func (z sourcesSecretData) FieldTransforms() map[string][]string { return z.fieldTransforms }
+
+func (z sourcesSecretData) FieldAttrs() map[string]backend.FieldAttr { return z.fieldAttrs }
+
+func newSourcesSecret(optSecret options.OptSecret) (*SourcesSecret, error) {
+	util.Tracef("newSourcesSecret(%+v)\n", optSecret)
+
+	r := &SourcesSecret{
+		optSecret: optSecret,
+		secrets:   make([]backend.Secret, 0, len(optSecret.Sources)),
+	}
+
+	for _, source := range optSecret.Sources {
+		secret, err := newSecret(SecretConfig{
+			OptSecret: options.OptSecret{
+				Backend: optSecret.Backend,
+				Vault:   source.Vault,
+			},
+		})
+		if err != nil {
+			r.Close()
+
+			return nil, fmt.Errorf("create %s source secret: %w", source.Name, err)
+		}
+
+		r.secrets = append(r.secrets, *secret)
+	}
+
+	return r, nil
+}
+
+func (z *SourcesSecret) Close() {
+	for _, secret := range z.secrets {
+		secret.Close()
+	}
+	z.secrets = nil
+}
+
+// Status returns the least favorable state of the sources (eg. the oldest
+// fetch time or the earliest expiration), and the versions of the sources as
+// "<source>=<version>" pairs.
+func (z *SourcesSecret) Status() backend.SecretStatus {
+	var r backend.SecretStatus
+	var versions []string
+
+	for i, secret := range z.secrets {
+		status := secret.Status()
+
+		if status.FetchedAt != nil && (r.FetchedAt == nil || status.FetchedAt.Before(*r.FetchedAt)) {
+			r.FetchedAt = status.FetchedAt
+		}
+
+		if status.ExpiresAt != nil && (r.ExpiresAt == nil || status.ExpiresAt.Before(*r.ExpiresAt)) {
+			r.ExpiresAt = status.ExpiresAt
+		}
+
+		if status.LeaseExpiresAt != nil && (r.LeaseExpiresAt == nil || status.LeaseExpiresAt.Before(*r.LeaseExpiresAt)) {
+			r.LeaseExpiresAt = status.LeaseExpiresAt
+		}
+
+		if status.LastError != nil && (r.LastErrorAt == nil || (status.LastErrorAt != nil && status.LastErrorAt.After(*r.LastErrorAt))) {
+			r.LastError = fmt.Errorf("%s source: %w", z.optSecret.Sources[i].Name, status.LastError)
+			r.LastErrorAt = status.LastErrorAt
+		}
+
+		if status.Version != nil {
+			versions = append(versions, z.optSecret.Sources[i].Name+"="+*status.Version)
+		}
+	}
+
+	if len(versions) > 0 {
+		version := strings.Join(versions, ",")
+		r.Version = &version
+	}
+
+	return r
+}
+
+func (z *SourcesSecret) GetData(noCache bool) (*backend.SecretData, error) {
+	util.Tracef("SourcesSecret[%v].GetData(%v)\n", z, noCache)
+
+	uniqueIds := make([]string, 0, len(z.secrets))
+	data := map[string]string{}
+	metadata := map[string]string{}
+	fieldTransforms := map[string][]string{}
+	fieldAttrs := map[string]backend.FieldAttr{}
+	conflicts := map[string]bool{}
+
+	var createdAt *time.Time
+
+	for i, secret := range z.secrets {
+		name := z.optSecret.Sources[i].Name
+
+		sourceData, err := secret.GetData(noCache)
+		if err != nil {
+			return nil, fmt.Errorf("get %s source data: %w", name, err)
+		}
+
+		uniqueIds = append(uniqueIds, (*sourceData).UniqueId())
+
+		if c := (*sourceData).CreatedAt(); c != nil && (createdAt == nil || c.After(*createdAt)) {
+			createdAt = c
+		}
+
+		// the metadata of each source are kept apart
+		for key, value := range (*sourceData).Metadata() {
+			if z.optSecret.SourcesLayout == options.SecretSourcesLayoutSubdir {
+				metadata[name+"/"+key] = value
+			} else {
+				metadata["."+name+"/"+key] = value
+			}
+		}
+
+		// merged sources declarations follow the fields conflict policy
+		for key, transforms := range (*sourceData).FieldTransforms() {
+			if z.optSecret.SourcesLayout == options.SecretSourcesLayoutSubdir {
+				fieldTransforms[name+"/"+key] = transforms
+			} else if _, ok := fieldTransforms[key]; !ok || z.optSecret.SourcesConflict == options.SecretSourcesConflictLast {
+				fieldTransforms[key] = transforms
+			}
+		}
+
+		for key, fieldAttr := range (*sourceData).FieldAttrs() {
+			if z.optSecret.SourcesLayout == options.SecretSourcesLayoutSubdir {
+				fieldAttrs[name+"/"+key] = fieldAttr
+			} else if _, ok := fieldAttrs[key]; !ok || z.optSecret.SourcesConflict == options.SecretSourcesConflictLast {
+				fieldAttrs[key] = fieldAttr
+			}
+		}
+
+		for _, key := range (*sourceData).GetKeys() {
+			value, ok := (*sourceData).GetValue(key)
+			if !ok {
+				continue
+			}
+
+			if z.optSecret.SourcesLayout == options.SecretSourcesLayoutSubdir {
+				data[name+"/"+key] = *value
+				continue
+			}
+
+			if _, ok := data[key]; ok {
+				switch z.optSecret.SourcesConflict {
+				case options.SecretSourcesConflictError:
+					conflicts[key] = true
+				case options.SecretSourcesConflictFirst:
+					continue
+				}
+			}
+
+			data[key] = *value
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("sources have conflicting fields: %s", strings.Join(slices.Sorted(maps.Keys(conflicts)), ", "))
+	}
+
+	var r backend.SecretData = sourcesSecretData{
+		// unchanged sources data does not create a new version
+		uniqueId:        strings.Join(uniqueIds, ","),
+		createdAt:       createdAt,
+		data:            data,
+		metadata:        metadata,
+		fieldTransforms: fieldTransforms,
+		fieldAttrs:      fieldAttrs,
+	}
+
+	return &r, nil
+}
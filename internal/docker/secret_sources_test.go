@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+)
+
+type testSecret struct {
+	data   backend.SecretData
+	err    error
+	status backend.SecretStatus
+
+	getDataCount int
+}
+
+func (z *testSecret) Close() {}
+
+func (z *testSecret) GetData(noCache bool) (*backend.SecretData, error) {
+	z.getDataCount++
+
+	if z.err != nil {
+		return nil, z.err
+	}
+
+	data := z.data
+	return &data, nil
+}
+
+func (z *testSecret) Status() backend.SecretStatus { return z.status }
+
+func newSourcesSecretForTest(layout string, conflict string, secrets ...*testSecret) *SourcesSecret {
+	r := &SourcesSecret{
+		optSecret: options.OptSecret{
+			SourcesLayout:   layout,
+			SourcesConflict: conflict,
+		},
+	}
+
+	for i, secret := range secrets {
+		r.optSecret.Sources = append(r.optSecret.Sources, options.OptSecretSource{Name: []string{"app", "db"}[i]})
+		r.secrets = append(r.secrets, secret)
+	}
+
+	return r
+}
+
+func TestSourcesSecretGetData(t *testing.T) {
+	appCreatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	dbCreatedAt := appCreatedAt.Add(time.Hour)
+
+	newSecrets := func() (*testSecret, *testSecret) {
+		appSecret := &testSecret{data: testSecretData{
+			uniqueId:   "1",
+			createdAt:  &appCreatedAt,
+			values:     map[string]string{"user": "app", "password": "app-password"},
+			metadata:   map[string]string{".version": "3"},
+			transforms: map[string][]string{"password": {"base64"}},
+		}}
+
+		dbSecret := &testSecret{data: testSecretData{
+			uniqueId:   "2",
+			createdAt:  &dbCreatedAt,
+			values:     map[string]string{"password": "db-password", "port": "5432"},
+			transforms: map[string][]string{"password": {"pem-split"}},
+		}}
+
+		return appSecret, dbSecret
+	}
+
+	for _, tc := range []struct {
+		name       string
+		layout     string
+		conflict   string
+		values     map[string]string
+		metadata   map[string]string
+		transforms map[string][]string
+	}{
+		{
+			name:       "subdir layout prefixes the fields by source",
+			layout:     options.SecretSourcesLayoutSubdir,
+			values:     map[string]string{"app/user": "app", "app/password": "app-password", "db/password": "db-password", "db/port": "5432"},
+			metadata:   map[string]string{"app/.version": "3"},
+			transforms: map[string][]string{"app/password": {"base64"}, "db/password": {"pem-split"}},
+		},
+		{
+			name:       "merge layout keeps the first source conflicting fields",
+			layout:     options.SecretSourcesLayoutMerge,
+			conflict:   options.SecretSourcesConflictFirst,
+			values:     map[string]string{"user": "app", "password": "app-password", "port": "5432"},
+			metadata:   map[string]string{".app/.version": "3"},
+			transforms: map[string][]string{"password": {"base64"}},
+		},
+		{
+			name:       "merge layout keeps the last source conflicting fields",
+			layout:     options.SecretSourcesLayoutMerge,
+			conflict:   options.SecretSourcesConflictLast,
+			values:     map[string]string{"user": "app", "password": "db-password", "port": "5432"},
+			metadata:   map[string]string{".app/.version": "3"},
+			transforms: map[string][]string{"password": {"pem-split"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			appSecret, dbSecret := newSecrets()
+
+			data, err := newSourcesSecretForTest(tc.layout, tc.conflict, appSecret, dbSecret).GetData(false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			values := map[string]string{}
+			for _, key := range (*data).GetKeys() {
+				value, _ := (*data).GetValue(key)
+				values[key] = *value
+			}
+
+			if !maps.Equal(values, tc.values) {
+				t.Errorf("expected fields %v, got %v", tc.values, values)
+			}
+
+			if !maps.Equal((*data).Metadata(), tc.metadata) {
+				t.Errorf("expected metadata %v, got %v", tc.metadata, (*data).Metadata())
+			}
+
+			if !maps.EqualFunc((*data).FieldTransforms(), tc.transforms, slices.Equal) {
+				t.Errorf("expected transforms %v, got %v", tc.transforms, (*data).FieldTransforms())
+			}
+
+			if (*data).UniqueId() != "1,2" {
+				t.Errorf("expected unique id 1,2, got %s", (*data).UniqueId())
+			}
+
+			if createdAt := (*data).CreatedAt(); createdAt == nil || !createdAt.Equal(dbCreatedAt) {
+				t.Errorf("expected the latest creation time, got %v", createdAt)
+			}
+		})
+	}
+
+	t.Run("merge layout conflicting fields return a sorted error", func(t *testing.T) {
+		appSecret, dbSecret := newSecrets()
+		appSecret.data.(testSecretData).values["port"] = "3306"
+
+		_, err := newSourcesSecretForTest(options.SecretSourcesLayoutMerge, options.SecretSourcesConflictError, appSecret, dbSecret).GetData(false)
+		if err == nil || err.Error() != "sources have conflicting fields: password, port" {
+			t.Errorf("expected the password and port conflicts error, got %v", err)
+		}
+	})
+
+	t.Run("source error returns error", func(t *testing.T) {
+		appSecret, dbSecret := newSecrets()
+		dbSecret.err = errors.New("permission denied")
+
+		_, err := newSourcesSecretForTest(options.SecretSourcesLayoutSubdir, "", appSecret, dbSecret).GetData(false)
+		if err == nil || err.Error() != "get db source data: permission denied" {
+			t.Errorf("expected the db source error, got %v", err)
+		}
+	})
+}
+
+func TestSourcesSecretStatus(t *testing.T) {
+	t.Run("least favorable state of the sources", func(t *testing.T) {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		at := func(d time.Duration) *time.Time {
+			r := now.Add(d)
+			return &r
+		}
+		version := "3"
+
+		z := newSourcesSecretForTest(options.SecretSourcesLayoutSubdir, "",
+			&testSecret{status: backend.SecretStatus{
+				FetchedAt: at(time.Minute),
+				ExpiresAt: at(time.Hour),
+				Version:   &version,
+			}},
+			&testSecret{status: backend.SecretStatus{
+				FetchedAt:      at(0),
+				ExpiresAt:      at(2 * time.Hour),
+				LeaseExpiresAt: at(24 * time.Hour),
+				LastError:      errors.New("permission denied"),
+				LastErrorAt:    at(2 * time.Minute),
+			}},
+		)
+
+		r := z.Status()
+
+		if r.FetchedAt == nil || !r.FetchedAt.Equal(*at(0)) {
+			t.Errorf("expected the oldest fetch time, got %v", r.FetchedAt)
+		}
+
+		if r.ExpiresAt == nil || !r.ExpiresAt.Equal(*at(time.Hour)) {
+			t.Errorf("expected the earliest expiration, got %v", r.ExpiresAt)
+		}
+
+		if r.LeaseExpiresAt == nil || !r.LeaseExpiresAt.Equal(*at(24 * time.Hour)) {
+			t.Errorf("expected the db lease expiration, got %v", r.LeaseExpiresAt)
+		}
+
+		if r.Version == nil || *r.Version != "app=3" {
+			t.Errorf("expected version app=3, got %v", r.Version)
+		}
+
+		if r.LastError == nil || r.LastError.Error() != "db source: permission denied" {
+			t.Errorf("expected the db source error, got %v", r.LastError)
+		}
+	})
+
+	t.Run("no source state is unknown", func(t *testing.T) {
+		r := newSourcesSecretForTest(options.SecretSourcesLayoutSubdir, "", &testSecret{}, &testSecret{}).Status()
+
+		if r.FetchedAt != nil || r.ExpiresAt != nil || r.LeaseExpiresAt != nil || r.Version != nil || r.LastError != nil {
+			t.Errorf("expected an unknown state, got %+v", r)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
+// secretDataFields returns the secret fields as a map, the metadata being
+// left out.
+func secretDataFields(data backend.SecretData) map[string]string {
+	r := map[string]string{}
+
+	for _, key := range data.GetKeys() {
+		value, ok := data.GetValue(key)
+		if ok {
+			r[key] = *value
+		}
+	}
+
+	return r
+}
+
+// renderSecretTemplate renders a Go template with the secret fields as data.
+func renderSecretTemplate(name string, text string, data backend.SecretData) ([]byte, error) {
+	tpl, err := template.New(name).Option("missingkey=error").Funcs(util.TemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, secretDataFields(data)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderVolumeTemplates renders the volume templates, the templates files
+// being read from templateDir.
+func renderVolumeTemplates(optDockerVolume options.OptDockerVolume, templateDir string, data backend.SecretData) map[string][]byte {
+	r := map[string][]byte{}
+
+	for name, text := range optDockerVolume.Templates {
+		content, err := renderSecretTemplate(name, text, data)
+		if err != nil {
+			util.Errorf("Unable to render %s template: %v\n", name, err)
+			continue
+		}
+
+		r[name] = content
+	}
+
+	for name, file := range optDockerVolume.TemplateFiles {
+		content, err := renderVolumeTemplateFile(name, templateDir, file, data)
+		if err != nil {
+			util.Errorf("Unable to render %s template: %v\n", name, err)
+			continue
+		}
+
+		r[name] = content
+	}
+
+	return r
+}
+
+func renderVolumeTemplateFile(name string, templateDir string, file string, data backend.SecretData) ([]byte, error) {
+	if templateDir == "" {
+		return nil, fmt.Errorf("templates directory is not defined")
+	}
+
+	text, err := os.ReadFile(filepath.Join(templateDir, file))
+	if err != nil {
+		return nil, fmt.Errorf("read template file: %w", err)
+	}
+
+	return renderSecretTemplate(name, string(text), data)
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
+// fieldTransforms returns the transforms of a field, the volume options
+// taking precedence over the ones declared by the secret.
+func fieldTransforms(opt options.OptDockerVolume, data backend.SecretData, key string) []string {
+	if transforms, ok := opt.FieldTransforms[key]; ok {
+		return transforms
+	}
+
+	return data.FieldTransforms()[key]
+}
+
+// transformField applies the transforms to a field value, returning the
+// resulting fields by name (several ones when the value is split).
+func transformField(key string, value string, transforms []string) (map[string][]byte, error) {
+	for i, transform := range transforms {
+		switch transform {
+		case options.FieldTransformBase64Decode:
+			decoded, err := util.DecodeBase64(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s transform: %w", transform, err)
+			}
+
+			value = string(decoded)
+
+		case options.FieldTransformTrim:
+			value = strings.TrimSpace(value)
+
+		case options.FieldTransformPemSplit:
+			if i != len(transforms)-1 {
+				return nil, fmt.Errorf("%s transform must be the last one", transform)
+			}
+
+			blocks, err := util.SplitPem(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s transform: %w", transform, err)
+			}
+
+			r := make(map[string][]byte, len(blocks))
+			for i, block := range blocks {
+				r[strings.TrimSuffix(key, ".pem")+"-"+strconv.Itoa(i)+".pem"] = []byte(block)
+			}
+
+			return r, nil
+
+		default:
+			return nil, fmt.Errorf("unknown transform %s", transform)
+		}
+	}
+
+	return map[string][]byte{key: []byte(value)}, nil
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"encoding/pem"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+)
+
+func TestTransformField(t *testing.T) {
+	firstBlock := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}}))
+	secondBlock := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{4, 5, 6}}))
+
+	t.Run("field without transform is kept as is", func(t *testing.T) {
+		r, err := transformField("password", " secret\n", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(r) != 1 || string(r["password"]) != " secret\n" {
+			t.Errorf("expected password to be kept as is, got %q", r)
+		}
+	})
+
+	t.Run("transforms are applied in order", func(t *testing.T) {
+		// unpadded, with line breaks
+		r, err := transformField("keystore", " cGFz\nc3dvcmQ \n", []string{options.FieldTransformBase64Decode, options.FieldTransformTrim})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(r["keystore"]) != "password" {
+			t.Errorf("expected keystore to be decoded, got %q", r["keystore"])
+		}
+	})
+
+	t.Run("pem-split names the files after the field", func(t *testing.T) {
+		for _, tc := range []struct {
+			key   string
+			names []string
+		}{
+			{"bundle.pem", []string{"bundle-0.pem", "bundle-1.pem"}},
+			{"chain", []string{"chain-0.pem", "chain-1.pem"}},
+		} {
+			r, err := transformField(tc.key, "leading text\n"+firstBlock+secondBlock, []string{options.FieldTransformTrim, options.FieldTransformPemSplit})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if names := slices.Sorted(maps.Keys(r)); !slices.Equal(names, tc.names) {
+				t.Errorf("%s: expected %v, got %v", tc.key, tc.names, names)
+				continue
+			}
+
+			if string(r[tc.names[0]]) != firstBlock || string(r[tc.names[1]]) != secondBlock {
+				t.Errorf("%s: expected a PEM block by file, got %q", tc.key, r)
+			}
+		}
+	})
+
+	t.Run("invalid transforms or values return error", func(t *testing.T) {
+		for _, tc := range []struct {
+			name       string
+			value      string
+			transforms []string
+		}{
+			{"pem-split not last", firstBlock, []string{options.FieldTransformPemSplit, options.FieldTransformTrim}},
+			{"invalid base64", "not base64!", []string{options.FieldTransformBase64Decode}},
+			{"no PEM block", "not a PEM block", []string{options.FieldTransformPemSplit}},
+			{"unknown transform", "value", []string{"rot13"}},
+		} {
+			if _, err := transformField("field", tc.value, tc.transforms); err == nil {
+				t.Errorf("%s: expected error", tc.name)
+			}
+		}
+	})
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/backend"
+	"github.com/hanwen/go-fuse/v2/fs"
+)
+
+const xattrNamePrefix = "user.vaultfs."
+
+// secretMetadataXattrName returns the extended attribute name of a metadata
+// pseudo-field name (without its leading dot).
+func secretMetadataXattrName(name string) string {
+	if k, ok := strings.CutPrefix(name, "metadata-"); ok {
+		return "metadata." + k
+	}
+
+	switch name {
+	case "version-metadata-version":
+		return "version"
+	case "lease-duration":
+		return "lease_ttl"
+	}
+
+	return strings.ReplaceAll(strings.TrimPrefix(name, "version-metadata-"), "-", "_")
+}
+
+// secretDataXattrs returns the extended attributes of a directory and its
+// files: the ones of its parent, overridden by the metadata of the directory.
+func secretDataXattrs(data backend.SecretData, dirPath string, parent map[string][]byte) map[string][]byte {
+	r := maps.Clone(parent)
+	if r == nil {
+		r = map[string][]byte{}
+
+		if createdAt := data.CreatedAt(); createdAt != nil {
+			r[xattrNamePrefix+"created_at"] = []byte(createdAt.UTC().Format(time.RFC3339))
+		}
+	}
+
+	prefix := ""
+	if dirPath != "" {
+		prefix = dirPath + fsSecretNestedFieldSeparator
+	}
+
+	for key, value := range data.Metadata() {
+		// the metadata of the subdirectories (eg. "app/.metadata-owner") are
+		// their own
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok || !strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, fsSecretReservedNamePrefix) {
+			continue
+		}
+
+		names := strings.Split(rest, fsSecretNestedFieldSeparator)
+		for i, name := range names {
+			names[i] = secretMetadataXattrName(strings.TrimPrefix(name, "."))
+		}
+
+		r[xattrNamePrefix+strings.Join(names, ".")] = []byte(value)
+	}
+
+	return r
+}
+
+func getxattr(xattrs map[string][]byte, attr string, dest []byte) (uint32, syscall.Errno) {
+	value, ok := xattrs[attr]
+	if !ok {
+		return 0, syscall.ENODATA
+	}
+
+	if len(dest) < len(value) {
+		return uint32(len(value)), syscall.ERANGE
+	}
+
+	return uint32(copy(dest, value)), fs.OK
+}
+
+func listxattr(xattrs map[string][]byte, dest []byte) (uint32, syscall.Errno) {
+	var r []byte
+	for _, name := range slices.Sorted(maps.Keys(xattrs)) {
+		r = append(r, name...)
+		r = append(r, 0)
+	}
+
+	if len(dest) < len(r) {
+		return uint32(len(r)), syscall.ERANGE
+	}
+
+	return uint32(copy(dest, r)), fs.OK
+}
@@ -65,12 +65,14 @@ func (z *Volume) mount(fs *Fs, requestId string) error {
 			return fmt.Errorf("create secret: %w", err)
 		}
 
-		fsInodeSecret := NewFsInodeSecret(*secret, z.OptDocker.DockerVolume)
+		fsInodeSecret := NewFsInodeSecret(*secret, z.OptDocker.DockerVolume, fs.TemplateDir)
 
 		if err := fs.InodeRoot.addInodeSecret(z.Name, fsInodeSecret); err != nil {
 			return fmt.Errorf("add secret inode to root inode: %w", err)
 		}
 
+		fsInodeSecret.StartRefresh()
+
 		z.fsInodeSecret = fsInodeSecret
 		z.secret = *secret
 		mountPath := path.Join(fs.MountDir, z.Name)
@@ -100,10 +102,11 @@ func (z *Volume) unmount(fs *Fs, requestId string) error {
 		delete(z.mountRequestIds, requestId)
 
 		z.mountPath = nil
-		z.secret.Close()
-		z.secret = nil
+		// stop the background refresh before closing the secret
 		z.fsInodeSecret.Close()
 		z.fsInodeSecret = nil
+		z.secret.Close()
+		z.secret = nil
 	}
 
 	return nil
@@ -124,10 +127,10 @@ func (z *Volume) forceUnmount(fs *Fs) error {
 		z.mountRequestIds = map[string]bool{}
 
 		z.mountPath = nil
-		z.secret.Close()
-		z.secret = nil
 		z.fsInodeSecret.Close()
 		z.fsInodeSecret = nil
+		z.secret.Close()
+		z.secret = nil
 	}
 
 	return nil
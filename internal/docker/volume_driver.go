@@ -6,10 +6,12 @@ package docker
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"sync"
+	"time"
 
 	dockerSdkPlugin "github.com/anthochamp/docker-plugin-vaultfs/internal/dockersdk/plugin"
 	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
@@ -19,12 +21,15 @@ import (
 const (
 	stateDirPerm  = 0o770
 	stateFilePerm = 0o600
+
+	stateBackupFileSuffix = ".bak"
 )
 
 type VolumeDriver struct {
 	VolumeDriverConfig
 
-	fs *Fs
+	fs               *Fs
+	stateKeyProvider stateKeyProvider
 
 	cleanUpLock *sync.Mutex
 
@@ -37,17 +42,24 @@ type VolumeDriver struct {
 type VolumeDriverConfig struct {
 	FsConfig FsConfig
 
-	GlobalScope   bool
-	StateFilePath string
+	GlobalScope     bool
+	StateFilePath   string
+	StateEncryption VolumeDriverStateEncryptionConfig
 
 	DefaultOptDocker options.OptDocker
 }
 
 func NewVolumeDriver(config VolumeDriverConfig) (*VolumeDriver, error) {
+	stateKeyProvider, err := newStateKeyProvider(config.StateEncryption, config.DefaultOptDocker.Secret.Vault)
+	if err != nil {
+		return nil, fmt.Errorf("create state encryption key provider: %w", err)
+	}
+
 	return &VolumeDriver{
 		VolumeDriverConfig: config,
 
-		fs: newFs(config.FsConfig),
+		fs:               newFs(config.FsConfig),
+		stateKeyProvider: stateKeyProvider,
 
 		cleanUpLock: &sync.Mutex{},
 
@@ -103,6 +115,10 @@ func (z *VolumeDriver) CleanUp() {
 	z.volumes = map[string]*Volume{}
 	z.volumesLock.Unlock()
 
+	if z.stateKeyProvider != nil {
+		z.stateKeyProvider.close()
+	}
+
 	z.doneChan <- true
 }
 
@@ -117,53 +133,175 @@ func (z VolumeDriver) backupVolumes() error {
 		return fmt.Errorf("create directory: %w", err)
 	}
 
-	var volumesBackup []VolumeConfig
+	var volumesBackup []json.RawMessage
 
 	z.volumesLock.RLock()
-	volumesBackup = make([]VolumeConfig, 0, len(z.volumes))
+	volumesBackup = make([]json.RawMessage, 0, len(z.volumes))
 	for _, v := range z.volumes {
-		volumesBackup = append(volumesBackup, v.VolumeConfig)
+		volumeData, err := json.Marshal(v.VolumeConfig)
+		if err != nil {
+			z.volumesLock.RUnlock()
+			return fmt.Errorf("serialize volume %s: %w", v.Name, err)
+		}
+
+		volumesBackup = append(volumesBackup, volumeData)
 	}
 	z.volumesLock.RUnlock()
 
-	fileData, err := json.Marshal(volumesBackup)
+	fileData, err := encodeStateFile(z.stateKeyProvider, volumesBackup)
 	if err != nil {
 		return fmt.Errorf("serialize volume backup data: %w", err)
 	}
 
-	return os.WriteFile(z.StateFilePath, fileData, stateFilePerm)
+	// keep the previous state as a backup copy, or the current backup copy
+	// when there is no previous state
+	backupFileData, err := os.ReadFile(z.StateFilePath)
+	if os.IsNotExist(err) {
+		backupFileData, err = os.ReadFile(z.StateFilePath + stateBackupFileSuffix)
+	}
+
+	if err == nil {
+		if z.stateKeyProvider != nil && !isEncryptedStateFile(backupFileData) {
+			// the state is being encrypted, its plaintext content must not be
+			// kept in the backup copy
+			backupFileData = fileData
+		}
+
+		if err := util.WriteFileAtomic(z.StateFilePath+stateBackupFileSuffix, backupFileData, stateFilePerm); err != nil {
+			return fmt.Errorf("write volume backup copy: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("read previous volume backup: %w", err)
+	}
+
+	return util.WriteFileAtomic(z.StateFilePath, fileData, stateFilePerm)
 }
 
 func (z VolumeDriver) restoreVolumes() error {
 	util.Tracef("VolumeDriver.restoreVolumes()\n")
 
-	content, err := os.ReadFile(z.StateFilePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
+	restoredFromBackupCopy := false
 
+	volumesData, quarantined, plaintext, err := z.readStateFile(z.StateFilePath)
+	if errors.Is(err, errStateVersionNewer) {
+		// the backup copy would miss the volumes of the newer version
 		return fmt.Errorf("read volume backup: %w", err)
+	} else if err != nil {
+		util.Errorf("Unable to read volume backup, trying its backup copy: %v\n", err)
+
+		if _, errb := os.Stat(z.StateFilePath + stateBackupFileSuffix); errb != nil {
+			return fmt.Errorf("read volume backup: %w", err)
+		}
+
+		var errb error
+		volumesData, quarantined, plaintext, errb = z.readStateFile(z.StateFilePath + stateBackupFileSuffix)
+		if errb != nil {
+			return fmt.Errorf("read volume backup: %w", err)
+		}
+
+		// set the unreadable state file aside so that it doesn't replace the backup copy
+		name := z.quarantineFilePath()
+		util.Errorf("Moving unreadable volume backup to %s\n", name)
+
+		if err := os.Rename(z.StateFilePath, name); err != nil {
+			return fmt.Errorf("move unreadable volume backup: %w", err)
+		}
+
+		restoredFromBackupCopy = true
 	}
 
-	var volumesData []VolumeConfig
-	err = json.Unmarshal(content, &volumesData)
-	if err != nil {
-		return fmt.Errorf("unserialize volume backup content: %w", err)
+	migrate := plaintext && z.stateKeyProvider != nil && (len(volumesData) > 0 || len(quarantined) > 0)
+	if migrate && !z.StateEncryption.MigratePlaintext {
+		return errors.New("volume backup is not encrypted, enable the plaintext state migration to encrypt it")
 	}
 
-	for _, volumeConfig := range volumesData {
+	for _, volumeData := range volumesData {
+		volumeConfig := VolumeConfig{
+			// options missing from older states keep their default value
+			OptDocker: options.MakeOptDocker(),
+		}
+
+		if err := json.Unmarshal(volumeData, &volumeConfig); err != nil {
+			util.Errorf("Unable to unserialize volume: %v\n", err)
+			quarantined = append(quarantined, volumeData)
+			continue
+		}
+
+		if _, ok := z.volumes[volumeConfig.Name]; ok || volumeConfig.Name == "" {
+			util.Errorf("Unable to restore volume with empty or duplicate name \"%s\"\n", volumeConfig.Name)
+			quarantined = append(quarantined, volumeData)
+			continue
+		}
+
+		if err := volumeConfig.OptDocker.NormalizeAndValidate(); err != nil {
+			util.Errorf("Unable to validate volume %s options: %v\n", volumeConfig.Name, err)
+			quarantined = append(quarantined, volumeData)
+			continue
+		}
+
 		volume, err := newVolume(volumeConfig)
 		if err != nil {
-			return fmt.Errorf("create volume %s: %w", volumeConfig.Name, err)
+			util.Errorf("Unable to create volume %s: %v\n", volumeConfig.Name, err)
+			quarantined = append(quarantined, volumeData)
+			continue
 		}
 
 		z.volumes[volumeConfig.Name] = volume
 	}
 
+	if len(quarantined) > 0 {
+		if err := z.quarantineVolumes(quarantined); err != nil {
+			return fmt.Errorf("quarantine volumes: %w", err)
+		}
+	}
+
+	if migrate || restoredFromBackupCopy || len(quarantined) > 0 {
+		if migrate {
+			util.Printf("Encrypting plaintext volume backup\n")
+		}
+
+		if err := z.backupVolumes(); err != nil {
+			return fmt.Errorf("rewrite volume backup: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// readStateFile reads the volumes from a state file. A missing state file
+// has no volume.
+func (z VolumeDriver) readStateFile(name string) (volumes []json.RawMessage, quarantined []json.RawMessage, plaintext bool, err error) {
+	fileContent, err := os.ReadFile(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, true, nil
+		}
+
+		return nil, nil, false, err
+	}
+
+	return decodeStateFile(z.stateKeyProvider, fileContent)
+}
+
+func (z VolumeDriver) quarantineFilePath() string {
+	return fmt.Sprintf("%s.quarantine-%s", z.StateFilePath, time.Now().UTC().Format("20060102T150405.000000000Z"))
+}
+
+// quarantineVolumes saves volumes which cannot be restored to a separate
+// state file, so that they can be recovered manually.
+func (z VolumeDriver) quarantineVolumes(volumes []json.RawMessage) error {
+	name := z.quarantineFilePath()
+
+	util.Errorf("Quarantining %d volume(s) to %s\n", len(volumes), name)
+
+	fileData, err := encodeStateFile(z.stateKeyProvider, volumes)
+	if err != nil {
+		return fmt.Errorf("serialize quarantined volumes: %w", err)
+	}
+
+	return util.WriteFileAtomic(name, fileData, stateFilePerm)
+}
+
 /***/
 
 func (z VolumeDriver) Create(r dockerSdkPlugin.VolumeDriverCreateRequest) error {
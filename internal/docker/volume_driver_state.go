@@ -0,0 +1,478 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	backendVault "github.com/anthochamp/docker-plugin-vaultfs/internal/backend/vault"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
+const (
+	stateCipherAes256Gcm = "aes-256-gcm"
+
+	stateKeySourceKeyFile    = "key-file"
+	stateKeySourcePassphrase = "passphrase"
+	stateKeySourceTransit    = "transit"
+
+	statePassphraseSaltSize      = 16
+	statePassphraseKdfIterations = 600000
+)
+
+// VolumeDriverStateEncryptionConfig holds the volume driver state file
+// encryption configuration. At most one key source can be defined.
+type VolumeDriverStateEncryptionConfig struct {
+	KeyFilePath      *string
+	Passphrase       *string
+	TransitKeyName   *string
+	TransitMountPath string
+
+	// MigratePlaintext allows restoring a plaintext state file, which is then
+	// written back encrypted.
+	MigratePlaintext bool
+}
+
+// volumeDriverStateVersion is the current version of the volumes schema in
+// the state file. It must be increased, along with a new entry in
+// volumeDriverStateMigrations, whenever VolumeConfig (or OptDocker) changes
+// in a way that older states cannot be unserialized as is.
+const volumeDriverStateVersion = 2
+
+// volumeDriverStateMigrations migrates a serialized volume from the version
+// of its index to the next one.
+var volumeDriverStateMigrations = []func(json.RawMessage) (json.RawMessage, error){
+	// 0 -> 1: the state file got versioned, volumes are unchanged
+	func(v json.RawMessage) (json.RawMessage, error) { return v, nil },
+	// 1 -> 2: the metadata dotfiles became optional, the volumes created
+	// before keep them
+	func(v json.RawMessage) (json.RawMessage, error) {
+		return setVolumeStateDefault(v, true, "OptDocker", "DockerVolume", "MetadataFiles")
+	},
+}
+
+// setVolumeStateDefault sets an option of a serialized volume, designated by
+// its path of JSON keys, unless it is already defined.
+func setVolumeStateDefault(volume json.RawMessage, value any, keys ...string) (json.RawMessage, error) {
+	var object map[string]json.RawMessage
+	if len(volume) > 0 {
+		if err := json.Unmarshal(volume, &object); err != nil {
+			return nil, err
+		}
+	}
+
+	if object == nil {
+		object = map[string]json.RawMessage{}
+	}
+
+	if len(keys) == 1 {
+		if _, ok := object[keys[0]]; ok {
+			return volume, nil
+		}
+
+		v, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		object[keys[0]] = v
+	} else {
+		v, err := setVolumeStateDefault(object[keys[0]], value, keys[1:]...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keys[0], err)
+		}
+
+		object[keys[0]] = v
+	}
+
+	return json.Marshal(object)
+}
+
+// errStateVersionNewer is returned when the state file has been written by a
+// newer version of the plugin.
+var errStateVersionNewer = errors.New("state version is newer than the supported version")
+
+// volumeDriverStateFile is the content of the state file. Versions before 1
+// were either a plaintext JSON array of volumes, or an encrypted state without
+// version nor authenticated header.
+type volumeDriverStateFile struct {
+	Version int `json:","`
+
+	// plaintext state
+	Volumes []json.RawMessage `json:",omitempty"`
+
+	// encrypted state, Data being the encrypted JSON array of volumes
+	Cipher    string          `json:",omitempty"`
+	KeySource string          `json:",omitempty"`
+	KeyParams *stateKeyParams `json:",omitempty"`
+	Data      []byte          `json:",omitempty"`
+}
+
+// header returns the serialized header of an encrypted state file, which is
+// authenticated along with the encrypted data so that it cannot be tampered
+// with.
+func (z volumeDriverStateFile) header() ([]byte, error) {
+	return json.Marshal(volumeDriverStateFile{
+		Version:   z.Version,
+		Cipher:    z.Cipher,
+		KeySource: z.KeySource,
+		KeyParams: z.KeyParams,
+	})
+}
+
+type stateKeyParams struct {
+	Salt       []byte `json:",omitempty"`
+	Iterations int    `json:",omitempty"`
+	WrappedKey string `json:",omitempty"`
+}
+
+// stateKeyProvider provides the keys used to encrypt the state file.
+type stateKeyProvider interface {
+	source() string
+
+	// currentKey returns the key to encrypt with and the parameters to store
+	// alongside the encrypted data to retrieve it.
+	currentKey() ([]byte, stateKeyParams, error)
+	// key returns the key matching the given parameters.
+	key(params stateKeyParams) ([]byte, error)
+
+	close()
+}
+
+func newStateKeyProvider(config VolumeDriverStateEncryptionConfig, optVault options.OptVault) (stateKeyProvider, error) {
+	sources := 0
+	for _, v := range []bool{config.KeyFilePath != nil, config.Passphrase != nil, config.TransitKeyName != nil} {
+		if v {
+			sources++
+		}
+	}
+
+	if sources > 1 {
+		return nil, errors.New("only one of key file, passphrase or transit key can be defined")
+	}
+
+	switch {
+	case config.KeyFilePath != nil:
+		key, err := util.AesKeyFromFile(*config.KeyFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("read key file %s: %w", *config.KeyFilePath, err)
+		}
+
+		return &stateKeyFileProvider{fileKey: key}, nil
+
+	case config.Passphrase != nil:
+		if *config.Passphrase == "" {
+			return nil, errors.New("passphrase cannot be empty")
+		}
+
+		return &statePassphraseProvider{passphrase: *config.Passphrase, lock: &sync.Mutex{}}, nil
+
+	case config.TransitKeyName != nil:
+		if err := optVault.ClientHttp.NormalizeAndValidate(); err != nil {
+			return nil, fmt.Errorf("vault client options: %w", err)
+		}
+
+		if err := optVault.VaultAuth.NormalizeAndValidate(); err != nil {
+			return nil, fmt.Errorf("vault auth options: %w", err)
+		}
+
+		transitKey, err := backendVault.NewVaultTransitKey(backendVault.VaultTransitKeyConfig{
+			OptVault:  optVault,
+			MountPath: config.TransitMountPath,
+			Name:      *config.TransitKeyName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create transit key: %w", err)
+		}
+
+		return &stateTransitProvider{transitKey: transitKey, lock: &sync.Mutex{}}, nil
+	}
+
+	return nil, nil
+}
+
+// encodeStateFile serializes the volumes into the state file content,
+// encrypting them when a key provider is defined.
+func encodeStateFile(keyProvider stateKeyProvider, volumes []json.RawMessage) ([]byte, error) {
+	stateFile := volumeDriverStateFile{
+		Version: volumeDriverStateVersion,
+	}
+
+	if keyProvider == nil {
+		stateFile.Volumes = volumes
+	} else {
+		content, err := json.Marshal(volumes)
+		if err != nil {
+			return nil, fmt.Errorf("serialize volumes: %w", err)
+		}
+
+		key, params, err := keyProvider.currentKey()
+		if err != nil {
+			return nil, fmt.Errorf("get encryption key: %w", err)
+		}
+
+		stateFile.Cipher = stateCipherAes256Gcm
+		stateFile.KeySource = keyProvider.source()
+		stateFile.KeyParams = &params
+
+		header, err := stateFile.header()
+		if err != nil {
+			return nil, fmt.Errorf("serialize header: %w", err)
+		}
+
+		data, err := util.EncryptAesGcm(key, content, header)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt: %w", err)
+		}
+
+		stateFile.Data = data
+	}
+
+	return json.Marshal(stateFile)
+}
+
+// decodeStateFile unserializes the volumes from the state file content,
+// decrypting them when needed, and migrates them to the current version.
+// Volumes which cannot be migrated are returned apart. It also reports whether
+// the state file was stored as plaintext.
+func decodeStateFile(keyProvider stateKeyProvider, fileContent []byte) (volumes []json.RawMessage, quarantined []json.RawMessage, plaintext bool, err error) {
+	var stateFile volumeDriverStateFile
+
+	if trimmed := bytes.TrimSpace(fileContent); len(trimmed) > 0 && trimmed[0] == '[' {
+		// version 0 plaintext state
+		if err := json.Unmarshal(fileContent, &stateFile.Volumes); err != nil {
+			return nil, nil, false, fmt.Errorf("unserialize state: %w", err)
+		}
+	} else if err := json.Unmarshal(fileContent, &stateFile); err != nil {
+		return nil, nil, false, fmt.Errorf("unserialize state: %w", err)
+	}
+
+	if stateFile.Version > volumeDriverStateVersion {
+		return nil, nil, false, fmt.Errorf("%w: %d > %d", errStateVersionNewer, stateFile.Version, volumeDriverStateVersion)
+	}
+
+	plaintext = stateFile.Cipher == ""
+	volumes = stateFile.Volumes
+
+	if !plaintext {
+		if keyProvider == nil {
+			return nil, nil, false, errors.New("state file is encrypted but no encryption key is defined")
+		}
+
+		if stateFile.Cipher != stateCipherAes256Gcm {
+			return nil, nil, false, fmt.Errorf("unknown cipher %s", stateFile.Cipher)
+		}
+
+		if stateFile.KeySource != keyProvider.source() {
+			return nil, nil, false, fmt.Errorf("state file is encrypted with a %s key, not a %s key", stateFile.KeySource, keyProvider.source())
+		}
+
+		var params stateKeyParams
+		if stateFile.KeyParams != nil {
+			params = *stateFile.KeyParams
+		}
+
+		key, err := keyProvider.key(params)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("get encryption key: %w", err)
+		}
+
+		var header []byte
+		if stateFile.Version > 0 {
+			// the header of the encrypted states without version is not
+			// authenticated
+			header, err = stateFile.header()
+			if err != nil {
+				return nil, nil, false, fmt.Errorf("serialize header: %w", err)
+			}
+		}
+
+		content, err := util.DecryptAesGcm(key, stateFile.Data, header)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("decrypt: %w", err)
+		}
+
+		if err := json.Unmarshal(content, &volumes); err != nil {
+			return nil, nil, false, fmt.Errorf("unserialize volumes: %w", err)
+		}
+	}
+
+	for version := stateFile.Version; version < volumeDriverStateVersion; version++ {
+		migratedVolumes := make([]json.RawMessage, 0, len(volumes))
+
+		for _, volume := range volumes {
+			migrated, err := volumeDriverStateMigrations[version](volume)
+			if err != nil {
+				util.Errorf("Unable to migrate volume from state version %d: %v\n", version, err)
+
+				quarantined = append(quarantined, volume)
+				continue
+			}
+
+			migratedVolumes = append(migratedVolumes, migrated)
+		}
+
+		volumes = migratedVolumes
+	}
+
+	return volumes, quarantined, plaintext, nil
+}
+
+// isEncryptedStateFile reports whether the state file content is an encrypted
+// state.
+func isEncryptedStateFile(fileContent []byte) bool {
+	var stateFile volumeDriverStateFile
+
+	if err := json.Unmarshal(fileContent, &stateFile); err != nil {
+		return false
+	}
+
+	return stateFile.Cipher != ""
+}
+
+/***/
+
+type stateKeyFileProvider struct {
+	fileKey []byte
+}
+
+func (z *stateKeyFileProvider) source() string { return stateKeySourceKeyFile }
+
+func (z *stateKeyFileProvider) currentKey() ([]byte, stateKeyParams, error) {
+	return z.fileKey, stateKeyParams{}, nil
+}
+
+func (z *stateKeyFileProvider) key(_ stateKeyParams) ([]byte, error) {
+	return z.fileKey, nil
+}
+
+func (z *stateKeyFileProvider) close() {}
+
+/***/
+
+type statePassphraseProvider struct {
+	passphrase string
+
+	// derived key cache, since key derivation is slow on purpose
+	lock          *sync.Mutex
+	derivedKey    []byte
+	derivedParams stateKeyParams
+}
+
+func (z *statePassphraseProvider) source() string { return stateKeySourcePassphrase }
+
+func (z *statePassphraseProvider) currentKey() ([]byte, stateKeyParams, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if z.derivedKey != nil {
+		return z.derivedKey, z.derivedParams, nil
+	}
+
+	salt := make([]byte, statePassphraseSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, stateKeyParams{}, err
+	}
+
+	params := stateKeyParams{Salt: salt, Iterations: statePassphraseKdfIterations}
+
+	key, err := util.AesKeyFromPassphrase(z.passphrase, params.Salt, params.Iterations)
+	if err != nil {
+		return nil, stateKeyParams{}, err
+	}
+
+	z.derivedKey = key
+	z.derivedParams = params
+
+	return key, params, nil
+}
+
+func (z *statePassphraseProvider) key(params stateKeyParams) ([]byte, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if z.derivedKey != nil && bytes.Equal(z.derivedParams.Salt, params.Salt) && z.derivedParams.Iterations == params.Iterations {
+		return z.derivedKey, nil
+	}
+
+	if len(params.Salt) == 0 || params.Iterations <= 0 {
+		return nil, errors.New("missing key derivation parameters")
+	}
+
+	key, err := util.AesKeyFromPassphrase(z.passphrase, params.Salt, params.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	z.derivedKey = key
+	z.derivedParams = params
+
+	return key, nil
+}
+
+func (z *statePassphraseProvider) close() {}
+
+/***/
+
+type stateTransitProvider struct {
+	transitKey *backendVault.VaultTransitKey
+
+	// data key cache, avoids a Vault round-trip on every state write
+	lock       *sync.Mutex
+	dataKey    []byte
+	wrappedKey string
+}
+
+func (z *stateTransitProvider) source() string { return stateKeySourceTransit }
+
+func (z *stateTransitProvider) currentKey() ([]byte, stateKeyParams, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if z.dataKey == nil {
+		dataKey, wrappedKey, err := z.transitKey.GenerateDataKey()
+		if err != nil {
+			return nil, stateKeyParams{}, fmt.Errorf("generate transit data key: %w", err)
+		}
+
+		z.dataKey = dataKey
+		z.wrappedKey = wrappedKey
+	}
+
+	return z.dataKey, stateKeyParams{WrappedKey: z.wrappedKey}, nil
+}
+
+func (z *stateTransitProvider) key(params stateKeyParams) ([]byte, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	if z.dataKey != nil && z.wrappedKey == params.WrappedKey {
+		return z.dataKey, nil
+	}
+
+	if params.WrappedKey == "" {
+		return nil, errors.New("missing wrapped data key")
+	}
+
+	dataKey, err := z.transitKey.UnwrapDataKey(params.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap transit data key: %w", err)
+	}
+
+	z.dataKey = dataKey
+	z.wrappedKey = params.WrappedKey
+
+	return dataKey, nil
+}
+
+func (z *stateTransitProvider) close() {
+	z.transitKey.Close()
+}
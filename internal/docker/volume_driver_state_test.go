@@ -0,0 +1,323 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
+func newStateKeyFileProviderForTest(t *testing.T) *stateKeyFileProvider {
+	key, err := util.NewAesKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return &stateKeyFileProvider{fileKey: key}
+}
+
+func TestVolumeDriverStateEncryption(t *testing.T) {
+	volumes := []json.RawMessage{json.RawMessage(`{"Name":"vol","Token":"s.secret-token"}`)}
+
+	t.Run("key file encrypted state is decrypted", func(t *testing.T) {
+		keyProvider := newStateKeyFileProviderForTest(t)
+
+		fileContent, err := encodeStateFile(keyProvider, volumes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if bytes.Contains(fileContent, []byte("s.secret-token")) {
+			t.Error("expected the state file not to contain the volumes in plaintext")
+		}
+
+		result, quarantined, plaintext, err := decodeStateFile(keyProvider, fileContent)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if plaintext || len(quarantined) != 0 {
+			t.Errorf("expected an encrypted state without quarantined volume, got plaintext=%v and %d", plaintext, len(quarantined))
+		}
+
+		if len(result) != 1 || string(result[0]) != string(volumes[0]) {
+			t.Errorf("expected %s, got %s", volumes, result)
+		}
+	})
+
+	t.Run("passphrase encrypted state is decrypted with a new provider", func(t *testing.T) {
+		fileContent, err := encodeStateFile(&statePassphraseProvider{passphrase: "passphrase", lock: &sync.Mutex{}}, volumes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		result, _, _, err := decodeStateFile(&statePassphraseProvider{passphrase: "passphrase", lock: &sync.Mutex{}}, fileContent)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(result) != 1 || string(result[0]) != string(volumes[0]) {
+			t.Errorf("expected %s, got %s", volumes, result)
+		}
+
+		if _, _, _, err := decodeStateFile(&statePassphraseProvider{passphrase: "wrong passphrase", lock: &sync.Mutex{}}, fileContent); err == nil {
+			t.Error("expected error for a wrong passphrase")
+		}
+	})
+
+	t.Run("wrong key returns error", func(t *testing.T) {
+		fileContent, err := encodeStateFile(newStateKeyFileProviderForTest(t), volumes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, _, _, err := decodeStateFile(newStateKeyFileProviderForTest(t), fileContent); err == nil {
+			t.Error("expected error for a wrong key")
+		}
+	})
+
+	t.Run("key source mismatch returns error", func(t *testing.T) {
+		fileContent, err := encodeStateFile(newStateKeyFileProviderForTest(t), volumes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, _, _, err := decodeStateFile(&statePassphraseProvider{passphrase: "passphrase", lock: &sync.Mutex{}}, fileContent); err == nil {
+			t.Error("expected error for a key source mismatch")
+		}
+	})
+
+	t.Run("encrypted state without key returns error", func(t *testing.T) {
+		fileContent, err := encodeStateFile(newStateKeyFileProviderForTest(t), volumes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, _, _, err := decodeStateFile(nil, fileContent); err == nil {
+			t.Error("expected error for an encrypted state without key")
+		}
+	})
+
+	t.Run("corrupted ciphertext returns error", func(t *testing.T) {
+		keyProvider := newStateKeyFileProviderForTest(t)
+
+		fileContent, err := encodeStateFile(keyProvider, volumes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var stateFile volumeDriverStateFile
+		if err := json.Unmarshal(fileContent, &stateFile); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		stateFile.Data[len(stateFile.Data)-1] ^= 0xff
+
+		fileContent, _ = json.Marshal(stateFile)
+
+		if _, _, _, err := decodeStateFile(keyProvider, fileContent); err == nil {
+			t.Error("expected error for a corrupted ciphertext")
+		}
+	})
+
+	t.Run("tampered header returns error", func(t *testing.T) {
+		keyProvider := newStateKeyFileProviderForTest(t)
+
+		fileContent, err := encodeStateFile(keyProvider, volumes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var stateFile volumeDriverStateFile
+		if err := json.Unmarshal(fileContent, &stateFile); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		stateFile.Version = 1
+
+		fileContent, _ = json.Marshal(stateFile)
+
+		if _, _, _, err := decodeStateFile(keyProvider, fileContent); err == nil {
+			t.Error("expected error for a tampered header")
+		}
+	})
+
+	t.Run("encrypted state without version is decrypted", func(t *testing.T) {
+		keyProvider := newStateKeyFileProviderForTest(t)
+
+		data, err := util.EncryptAesGcm(keyProvider.fileKey, []byte(`[{"Name":"vol"}]`), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		fileContent, err := json.Marshal(volumeDriverStateFile{
+			Cipher:    stateCipherAes256Gcm,
+			KeySource: stateKeySourceKeyFile,
+			KeyParams: &stateKeyParams{},
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		result, _, plaintext, err := decodeStateFile(keyProvider, fileContent)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if plaintext || len(result) != 1 {
+			t.Fatalf("expected 1 encrypted volume, got plaintext=%v and %d", plaintext, len(result))
+		}
+
+		var volumeConfig VolumeConfig
+		if err := json.Unmarshal(result[0], &volumeConfig); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if volumeConfig.Name != "vol" || !volumeConfig.OptDocker.DockerVolume.MetadataFiles {
+			t.Errorf("expected migrated volume vol, got %s", result[0])
+		}
+	})
+
+	t.Run("plaintext version 0 state is reported as plaintext", func(t *testing.T) {
+		result, _, plaintext, err := decodeStateFile(newStateKeyFileProviderForTest(t), []byte(`[{"Name":"vol"}]`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !plaintext || len(result) != 1 {
+			t.Errorf("expected 1 plaintext volume, got plaintext=%v and %d", plaintext, len(result))
+		}
+	})
+
+	t.Run("plaintext version 0 state is encrypted when migrating", func(t *testing.T) {
+		driver := newVolumeDriverForTest(t, newStateKeyFileProviderForTest(t))
+		driver.StateEncryption.MigratePlaintext = true
+
+		if err := os.WriteFile(driver.StateFilePath, []byte(`[`+string(newVolumeStateForTest(t, "vol"))+`]`), stateFilePerm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := driver.restoreVolumes(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, ok := driver.volumes["vol"]; !ok {
+			t.Errorf("expected volume vol to be restored, got %v", driver.volumes)
+		}
+
+		fileContent, err := os.ReadFile(driver.StateFilePath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !isEncryptedStateFile(fileContent) {
+			t.Error("expected the state file to be encrypted")
+		}
+	})
+
+	t.Run("plaintext version 0 state without migration returns error", func(t *testing.T) {
+		driver := newVolumeDriverForTest(t, newStateKeyFileProviderForTest(t))
+
+		fileContent := []byte(`[` + string(newVolumeStateForTest(t, "vol")) + `]`)
+		if err := os.WriteFile(driver.StateFilePath, fileContent, stateFilePerm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := driver.restoreVolumes(); err == nil {
+			t.Error("expected error for a plaintext state without migration")
+		}
+
+		result, _ := os.ReadFile(driver.StateFilePath)
+		if !bytes.Equal(result, fileContent) {
+			t.Error("expected the plaintext state file to be left untouched")
+		}
+	})
+}
+
+func TestVolumeDriverStateMigrations(t *testing.T) {
+	t.Run("volumes created before version 2 keep the metadata files", func(t *testing.T) {
+		volumes, quarantined, _, err := decodeStateFile(nil, []byte(`{"Version":1,"Volumes":[{"Name":"vol","OptDocker":{"DockerVolume":{"MountMode":360}}}]}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(volumes) != 1 || len(quarantined) != 0 {
+			t.Fatalf("expected 1 volume and no quarantined volume, got %d and %d", len(volumes), len(quarantined))
+		}
+
+		var volumeConfig VolumeConfig
+		if err := json.Unmarshal(volumes[0], &volumeConfig); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !volumeConfig.OptDocker.DockerVolume.MetadataFiles {
+			t.Error("expected MetadataFiles=true")
+		}
+
+		if volumeConfig.OptDocker.DockerVolume.MountMode != 360 {
+			t.Errorf("expected MountMode=360, got %d", volumeConfig.OptDocker.DockerVolume.MountMode)
+		}
+	})
+
+	t.Run("plaintext version 0 volumes without options keep the metadata files", func(t *testing.T) {
+		volumes, _, _, err := decodeStateFile(nil, []byte(`[{"Name":"vol"}]`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var volumeConfig VolumeConfig
+		if err := json.Unmarshal(volumes[0], &volumeConfig); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if volumeConfig.Name != "vol" || !volumeConfig.OptDocker.DockerVolume.MetadataFiles {
+			t.Errorf("expected volume vol with MetadataFiles=true, got %+v", volumeConfig)
+		}
+	})
+
+	t.Run("defined metadata files option is kept", func(t *testing.T) {
+		volumes, _, _, err := decodeStateFile(nil, []byte(`{"Version":1,"Volumes":[{"Name":"vol","OptDocker":{"DockerVolume":{"MetadataFiles":false}}}]}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var volumeConfig VolumeConfig
+		if err := json.Unmarshal(volumes[0], &volumeConfig); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if volumeConfig.OptDocker.DockerVolume.MetadataFiles {
+			t.Error("expected MetadataFiles=false")
+		}
+	})
+
+	t.Run("current version volumes are not migrated", func(t *testing.T) {
+		volumes, _, _, err := decodeStateFile(nil, []byte(`{"Version":2,"Volumes":[{"Name":"vol"}]}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(volumes[0]) != `{"Name":"vol"}` {
+			t.Errorf("expected unchanged volume, got %s", volumes[0])
+		}
+	})
+
+	t.Run("invalid volumes are quarantined", func(t *testing.T) {
+		volumes, quarantined, _, err := decodeStateFile(nil, []byte(`{"Version":1,"Volumes":[{"Name":"vol"},["not a volume"]]}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(volumes) != 1 || len(quarantined) != 1 {
+			t.Errorf("expected 1 volume and 1 quarantined volume, got %d and %d", len(volumes), len(quarantined))
+		}
+	})
+}
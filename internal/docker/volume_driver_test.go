@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/options"
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
+func newVolumeDriverForTest(t *testing.T, keyProvider stateKeyProvider) *VolumeDriver {
+	return &VolumeDriver{
+		VolumeDriverConfig: VolumeDriverConfig{
+			StateFilePath: path.Join(t.TempDir(), "volumes.json"),
+		},
+
+		stateKeyProvider: keyProvider,
+
+		volumesLock: &sync.RWMutex{},
+		volumes:     map[string]*Volume{},
+	}
+}
+
+func newVolumeStateForTest(t *testing.T, name string) json.RawMessage {
+	defaultOptDocker := options.MakeOptDocker()
+
+	optDocker, err := options.NewOptDockerFromDockerVolume(name, map[string]string{"auth-token": "s.secret-token"}, &defaultOptDocker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	volumeData, err := json.Marshal(VolumeConfig{Name: name, OptDocker: *optDocker})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return volumeData
+}
+
+func writeStateFileForTest(t *testing.T, name string, keyProvider stateKeyProvider, volumes ...json.RawMessage) {
+	fileData, err := encodeStateFile(keyProvider, volumes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(name, fileData, stateFilePerm); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVolumeDriverRestoreVolumes(t *testing.T) {
+	t.Run("unreadable state file falls back to its backup copy", func(t *testing.T) {
+		driver := newVolumeDriverForTest(t, nil)
+
+		if err := os.WriteFile(driver.StateFilePath, []byte("{not json"), stateFilePerm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		writeStateFileForTest(t, driver.StateFilePath+stateBackupFileSuffix, nil, newVolumeStateForTest(t, "vol"))
+
+		if err := driver.restoreVolumes(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, ok := driver.volumes["vol"]; !ok || len(driver.volumes) != 1 {
+			t.Errorf("expected volume vol to be restored, got %v", driver.volumes)
+		}
+
+		quarantineFiles, _ := filepath.Glob(driver.StateFilePath + ".quarantine-*")
+		if len(quarantineFiles) != 1 {
+			t.Fatalf("expected the unreadable state file to be quarantined, got %v", quarantineFiles)
+		}
+
+		quarantineFileData, _ := os.ReadFile(quarantineFiles[0])
+		if string(quarantineFileData) != "{not json" {
+			t.Errorf("expected the quarantined file to be the unreadable state file, got %q", quarantineFileData)
+		}
+
+		volumes, _, _, err := driver.readStateFile(driver.StateFilePath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(volumes) != 1 {
+			t.Errorf("expected the state file to be rewritten with 1 volume, got %d", len(volumes))
+		}
+	})
+
+	t.Run("unreadable state file and backup copy return error", func(t *testing.T) {
+		driver := newVolumeDriverForTest(t, nil)
+
+		if err := os.WriteFile(driver.StateFilePath, []byte("{not json"), stateFilePerm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := os.WriteFile(driver.StateFilePath+stateBackupFileSuffix, []byte("{not json either"), stateFilePerm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := driver.restoreVolumes(); err == nil {
+			t.Error("expected error when neither the state file nor its backup copy can be read")
+		}
+	})
+
+	t.Run("undecodable volumes are quarantined", func(t *testing.T) {
+		driver := newVolumeDriverForTest(t, nil)
+
+		writeStateFileForTest(t, driver.StateFilePath, nil,
+			newVolumeStateForTest(t, "vol"),
+			json.RawMessage(`{"Name":"bad","OptDocker":"not options"}`),
+			json.RawMessage(`{"Name":""}`),
+		)
+
+		if err := driver.restoreVolumes(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, ok := driver.volumes["vol"]; !ok || len(driver.volumes) != 1 {
+			t.Errorf("expected only volume vol to be restored, got %v", driver.volumes)
+		}
+
+		quarantineFiles, _ := filepath.Glob(driver.StateFilePath + ".quarantine-*")
+		if len(quarantineFiles) != 1 {
+			t.Fatalf("expected 1 quarantine file, got %v", quarantineFiles)
+		}
+
+		quarantined, _, _, err := driver.readStateFile(quarantineFiles[0])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(quarantined) != 2 {
+			t.Errorf("expected 2 quarantined volumes, got %d", len(quarantined))
+		}
+
+		volumes, _, _, err := driver.readStateFile(driver.StateFilePath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(volumes) != 1 {
+			t.Errorf("expected the state file to be rewritten with 1 volume, got %d", len(volumes))
+		}
+	})
+
+	t.Run("newer state version returns error", func(t *testing.T) {
+		driver := newVolumeDriverForTest(t, nil)
+
+		if err := os.WriteFile(driver.StateFilePath, []byte(`{"Version":999,"Volumes":[]}`), stateFilePerm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := driver.restoreVolumes(); err == nil {
+			t.Error("expected error for a newer state version")
+		}
+
+		fileData, _ := os.ReadFile(driver.StateFilePath)
+		if string(fileData) != `{"Version":999,"Volumes":[]}` {
+			t.Errorf("expected the newer state file to be left untouched, got %q", fileData)
+		}
+	})
+
+	t.Run("plaintext state migration does not keep a plaintext backup copy", func(t *testing.T) {
+		key, err := util.NewAesKey()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		driver := newVolumeDriverForTest(t, &stateKeyFileProvider{fileKey: key})
+		driver.StateEncryption.MigratePlaintext = true
+
+		writeStateFileForTest(t, driver.StateFilePath, nil, newVolumeStateForTest(t, "vol"))
+		writeStateFileForTest(t, driver.StateFilePath+stateBackupFileSuffix, nil, newVolumeStateForTest(t, "vol"))
+
+		if err := driver.restoreVolumes(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, name := range []string{driver.StateFilePath, driver.StateFilePath + stateBackupFileSuffix} {
+			fileData, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if bytes.Contains(fileData, []byte("s.secret-token")) || !isEncryptedStateFile(fileData) {
+				t.Errorf("expected %s to be encrypted", path.Base(name))
+			}
+		}
+	})
+
+	t.Run("plaintext state without migration returns error", func(t *testing.T) {
+		key, err := util.NewAesKey()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		driver := newVolumeDriverForTest(t, &stateKeyFileProvider{fileKey: key})
+
+		writeStateFileForTest(t, driver.StateFilePath, nil, newVolumeStateForTest(t, "vol"))
+
+		if err := driver.restoreVolumes(); err == nil {
+			t.Error("expected error for a plaintext state without migration")
+		}
+	})
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/constants"
+)
+
+// dockerSecretLabelsForbiddenPrefixes are the prefixes of the volume options
+// which cannot be set from labels: anyone able to label a Docker secret or
+// service could otherwise have the plugin send its default Vault credentials
+// to another server, or read files of the host. These options always use the
+// plugin default values.
+var dockerSecretLabelsForbiddenPrefixes = []string{"vault-", "auth-"}
+
+// volumeOptionsFromDockerSecretLabels extracts the plugin labels (eg.
+// "vaultfs.engine-mount") from the Docker secret labels and the Docker service
+// labels into options named after the Docker volume options. Service labels
+// take precedence over secret labels. The client, auth and file options are
+// rejected.
+func volumeOptionsFromDockerSecretLabels(secretLabels map[string]string, serviceLabels map[string]string) (map[string]string, error) {
+	r := map[string]string{}
+
+	for _, labels := range []map[string]string{secretLabels, serviceLabels} {
+		for k, v := range labels {
+			name, ok := strings.CutPrefix(k, constants.DockerLabelsPrefix)
+			if !ok || name == "" {
+				continue
+			}
+
+			if err := validateDockerSecretLabelOption(name); err != nil {
+				return nil, fmt.Errorf("label %s: %w", k, err)
+			}
+
+			r[name] = v
+		}
+	}
+
+	return r, nil
+}
+
+func validateDockerSecretLabelOption(name string) error {
+	for _, prefix := range dockerSecretLabelsForbiddenPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("option %s cannot be set from a label", name)
+		}
+	}
+
+	if strings.HasSuffix(name, "-file") || strings.Contains(name, "-file-") {
+		return fmt.Errorf("file option %s cannot be set from a label", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"testing"
+)
+
+func TestVolumeOptionsFromDockerSecretLabels(t *testing.T) {
+	t.Run("only prefixed labels are kept", func(t *testing.T) {
+		result, err := volumeOptionsFromDockerSecretLabels(map[string]string{
+			"vaultfs.engine-mount": "app",
+			"vaultfs.":             "ignored",
+			"com.example.label":    "ignored",
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(result) != 1 {
+			t.Fatalf("expected 1 option, got %v", result)
+		}
+
+		if result["engine-mount"] != "app" {
+			t.Errorf("expected engine-mount=app, got %q", result["engine-mount"])
+		}
+	})
+
+	t.Run("service labels take precedence over secret labels", func(t *testing.T) {
+		result, err := volumeOptionsFromDockerSecretLabels(
+			map[string]string{"vaultfs.secret": "from-secret", "vaultfs.engine-mount": "app"},
+			map[string]string{"vaultfs.secret": "from-service"},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if result["secret"] != "from-service" {
+			t.Errorf("expected secret=from-service, got %q", result["secret"])
+		}
+
+		if result["engine-mount"] != "app" {
+			t.Errorf("expected engine-mount=app, got %q", result["engine-mount"])
+		}
+	})
+
+	t.Run("client, auth and file options are rejected", func(t *testing.T) {
+		for _, label := range []string{
+			"vaultfs.vault-url",
+			"vaultfs.vault-ca-cert-file",
+			"vaultfs.vault-tls-skip-verify",
+			"vaultfs.auth-method",
+			"vaultfs.auth-token",
+			"vaultfs.auth-secret-id-file",
+			"vaultfs.template-file-app.conf",
+		} {
+			if _, err := volumeOptionsFromDockerSecretLabels(nil, map[string]string{label: "value"}); err == nil {
+				t.Errorf("expected error for label %s", label)
+			}
+		}
+	})
+}
+
+func TestNewOptDockerFromDockerSecret(t *testing.T) {
+	t.Run("labels are applied over the default config", func(t *testing.T) {
+		token := "default-token"
+		defaultConfig := MakeOptDocker()
+		defaultConfig.Secret.Vault.ClientHttp.Address = "https://vault.example.com:8200"
+		defaultConfig.Secret.Vault.VaultAuth.Token = &token
+
+		opt, err := NewOptDockerFromDockerSecret("db_password", map[string]string{
+			"vaultfs.engine-mount":      "app",
+			"vaultfs.kv-engine-version": "2",
+			"vaultfs.secret":            "database",
+			"vaultfs.kv-secret-version": "3",
+		}, nil, &defaultConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Secret.Vault.ClientHttp.Address != "https://vault.example.com:8200" {
+			t.Errorf("expected default vault address, got %q", opt.Secret.Vault.ClientHttp.Address)
+		}
+
+		if opt.Secret.Vault.VaultAuth.Token == nil || *opt.Secret.Vault.VaultAuth.Token != token {
+			t.Errorf("expected default token, got %v", opt.Secret.Vault.VaultAuth.Token)
+		}
+
+		if opt.Secret.Vault.VaultEngine.EffectiveMountPath() != "app" {
+			t.Errorf("expected engine mount app, got %q", opt.Secret.Vault.VaultEngine.EffectiveMountPath())
+		}
+
+		if opt.Secret.Vault.VaultEngine.KvVersion != 2 {
+			t.Errorf("expected KvVersion=2, got %d", opt.Secret.Vault.VaultEngine.KvVersion)
+		}
+
+		if opt.Secret.Vault.VaultSecret.Path != "database" {
+			t.Errorf("expected path database, got %q", opt.Secret.Vault.VaultSecret.Path)
+		}
+
+		if opt.Secret.Vault.VaultSecret.KvVersion == nil || *opt.Secret.Vault.VaultSecret.KvVersion != 3 {
+			t.Errorf("expected KvVersion=3, got %v", opt.Secret.Vault.VaultSecret.KvVersion)
+		}
+	})
+
+	t.Run("secret name is used as secret path without label", func(t *testing.T) {
+		token := "default-token"
+		defaultConfig := MakeOptDocker()
+		defaultConfig.Secret.Vault.VaultAuth.Token = &token
+
+		opt, err := NewOptDockerFromDockerSecret("credentials", nil, nil, &defaultConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Secret.Vault.VaultSecret.Path != "credentials" {
+			t.Errorf("expected path credentials, got %q", opt.Secret.Vault.VaultSecret.Path)
+		}
+	})
+
+	t.Run("vault-url label does not redirect the default credentials", func(t *testing.T) {
+		token := "default-token"
+		defaultConfig := MakeOptDocker()
+		defaultConfig.Secret.Vault.ClientHttp.Address = "https://vault.example.com:8200"
+		defaultConfig.Secret.Vault.VaultAuth.Token = &token
+
+		if _, err := NewOptDockerFromDockerSecret("credentials", nil, map[string]string{"vaultfs.vault-url": "https://attacker.example.com"}, &defaultConfig); err == nil {
+			t.Error("expected error for vault-url label")
+		}
+	})
+
+	t.Run("invalid label value returns error", func(t *testing.T) {
+		token := "default-token"
+		defaultConfig := MakeOptDocker()
+		defaultConfig.Secret.Vault.VaultAuth.Token = &token
+
+		_, err := NewOptDockerFromDockerSecret("credentials", map[string]string{"vaultfs.engine-type": "unknown"}, nil, &defaultConfig)
+
+		if err == nil {
+			t.Error("expected error for unknown engine type label")
+		}
+	})
+}
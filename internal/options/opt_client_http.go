@@ -5,9 +5,9 @@
 package options
 
 import (
-	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 )
 
 type OptClientHttp struct {
@@ -19,7 +19,7 @@ type OptClientHttp struct {
 func (z OptClientHttp) CacheId_() string {
 	r := ""
 
-	r = z.Address
+	r = strconv.Quote(z.Address)
 
 	if z.DisableRedirects {
 		r += "1"
@@ -59,6 +59,10 @@ func NewOptClientHttpFromDockerVolume(volumeName string, volumeOptions map[strin
 func NewOptClientHttpFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string, defaultConfig *OptClientHttp) (*OptClientHttp, error) {
 	var r OptClientHttp
 
+	if defaultConfig != nil {
+		r = *defaultConfig
+	}
+
 	if err := r.UpdateFromDockerSecret(secretName, secretLabels, serviceLabels); err != nil {
 		return nil, err
 	}
@@ -70,14 +74,19 @@ func NewOptClientHttpFromDockerSecret(secretName string, secretLabels map[string
 	return &r, nil
 }
 
-func (z *OptClientHttp) UpdateFromDockerVolume(_ string, volumeOptions map[string]string) error {
-	// TODO
+func (z *OptClientHttp) UpdateFromDockerVolume(volumeName string, volumeOptions map[string]string) error {
+	v, ok := volumeOptions["vault-url"]
+	if ok {
+		z.Address = v
+	}
 
-	return nil
+	return z.Tls.UpdateFromDockerVolume(volumeName, volumeOptions)
 }
 
 func (z *OptClientHttp) UpdateFromDockerSecret(_ string, _ map[string]string, _ map[string]string) error {
-	return errors.New("not implemented")
+	// the client options cannot be set from labels (cf.
+	// volumeOptionsFromDockerSecretLabels), the plugin default ones are kept
+	return nil
 }
 
 func (z *OptClientHttp) Normalize() {
@@ -91,5 +100,9 @@ func (z *OptClientHttp) NormalizeAndValidate() error {
 		return fmt.Errorf("address is invalid: %w", err)
 	}
 
+	if err := z.Tls.NormalizeAndValidate(); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"testing"
+)
+
+func TestOptClientHttpUpdateFromDockerVolume(t *testing.T) {
+	t.Run("vault-url option overrides the default address", func(t *testing.T) {
+		opt := MakeOptClientHttp()
+		opt.Address = "https://vault.example.com:8200"
+
+		if err := opt.UpdateFromDockerVolume("my/secret", map[string]string{"vault-url": "https://staging.example.com:8200"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Address != "https://staging.example.com:8200" {
+			t.Errorf("expected address %q, got %q", "https://staging.example.com:8200", opt.Address)
+		}
+	})
+
+	t.Run("tls options are forwarded", func(t *testing.T) {
+		opt := MakeOptClientHttp()
+
+		if err := opt.UpdateFromDockerVolume("my/secret", map[string]string{"vault-tls-server-name": "vault.internal"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Tls.ServerName == nil || *opt.Tls.ServerName != "vault.internal" {
+			t.Errorf("expected ServerName=vault.internal, got %v", opt.Tls.ServerName)
+		}
+	})
+}
+
+func TestOptClientHttpCacheId(t *testing.T) {
+	t.Run("different addresses have different cache ids", func(t *testing.T) {
+		a := MakeOptClientHttp()
+		a.Address = "https://prod.example.com"
+		b := MakeOptClientHttp()
+		b.Address = "https://staging.example.com"
+
+		if a.CacheId_() == b.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+}
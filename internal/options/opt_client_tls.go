@@ -6,7 +6,9 @@ package options
 
 import (
 	"errors"
+	"fmt"
 	"path"
+	"strconv"
 )
 
 // OptClientTls holds TLS configuration options for Vault or HTTP clients.
@@ -19,6 +21,7 @@ type OptClientTls struct {
 }
 
 // CacheId_ returns a unique string representing the TLS config for caching purposes.
+// String values are quoted so that distinct configurations cannot collide.
 func (z OptClientTls) CacheId_() string {
 	r := ""
 
@@ -28,28 +31,12 @@ func (z OptClientTls) CacheId_() string {
 		r += "0"
 	}
 
-	if z.CACertFile != nil {
-		r += *z.CACertFile
-	} else {
-		r += "nil"
-	}
-
-	if z.CertFile != nil {
-		r += *z.CertFile
-	} else {
-		r += "nil"
-	}
-
-	if z.KeyFile != nil {
-		r += *z.KeyFile
-	} else {
-		r += "nil"
-	}
-
-	if z.ServerName != nil {
-		r += *z.ServerName
-	} else {
-		r += "nil"
+	for _, v := range []*string{z.CACertFile, z.CertFile, z.KeyFile, z.ServerName} {
+		if v != nil {
+			r += strconv.Quote(*v)
+		} else {
+			r += "nil"
+		}
 	}
 
 	return r
@@ -83,6 +70,10 @@ func NewOptClientTlsFromDockerVolume(volumeName string, volumeOptions map[string
 func NewOptClientTlsFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string, defaultConfig *OptClientTls) (*OptClientTls, error) {
 	var r OptClientTls
 
+	if defaultConfig != nil {
+		r = *defaultConfig
+	}
+
 	if err := r.UpdateFromDockerSecret(secretName, secretLabels, serviceLabels); err != nil {
 		return nil, err
 	}
@@ -96,14 +87,41 @@ func NewOptClientTlsFromDockerSecret(secretName string, secretLabels map[string]
 
 // UpdateFromDockerVolume updates the OptClientTls from Docker volume options.
 func (z *OptClientTls) UpdateFromDockerVolume(_ string, volumeOptions map[string]string) error {
-	// TODO
+	v, ok := volumeOptions["vault-tls-skip-verify"]
+	if ok {
+		vtsv, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("unable to convert vault-tls-skip-verify value %s to boolean", v)
+		}
+
+		z.Insecure = vtsv
+	}
+
+	vcacf, ok := volumeOptions["vault-ca-cert-file"]
+	if ok {
+		z.CACertFile = &vcacf
+	}
+	vccf, ok := volumeOptions["vault-client-cert-file"]
+	if ok {
+		z.CertFile = &vccf
+	}
+	vckf, ok := volumeOptions["vault-client-key-file"]
+	if ok {
+		z.KeyFile = &vckf
+	}
+	vtsn, ok := volumeOptions["vault-tls-server-name"]
+	if ok {
+		z.ServerName = &vtsn
+	}
 
 	return nil
 }
 
 // UpdateFromDockerSecret updates the OptClientTls from Docker secret and service labels.
 func (z *OptClientTls) UpdateFromDockerSecret(_ string, _ map[string]string, _ map[string]string) error {
-	return errors.New("not implemented")
+	// the TLS options cannot be set from labels (cf.
+	// volumeOptionsFromDockerSecretLabels), the plugin default ones are kept
+	return nil
 }
 
 // Normalize cleans up and standardizes the OptClientTls fields.
@@ -144,5 +162,9 @@ func (z *OptClientTls) Normalize() {
 func (z *OptClientTls) NormalizeAndValidate() error {
 	z.Normalize()
 
+	if (z.CertFile == nil) != (z.KeyFile == nil) {
+		return errors.New("client certificate and key files must be defined together")
+	}
+
 	return nil
 }
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"testing"
+)
+
+func TestOptClientTlsUpdateFromDockerVolume(t *testing.T) {
+	t.Run("tls options are parsed", func(t *testing.T) {
+		opt := MakeOptClientTls()
+
+		if err := opt.UpdateFromDockerVolume("my/secret", map[string]string{
+			"vault-ca-cert-file":     "/etc/vault/ca.pem",
+			"vault-client-cert-file": "/etc/vault/client.pem",
+			"vault-client-key-file":  "/etc/vault/client-key.pem",
+			"vault-tls-server-name":  "vault.internal",
+			"vault-tls-skip-verify":  "true",
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.CACertFile == nil || *opt.CACertFile != "/etc/vault/ca.pem" {
+			t.Errorf("expected CACertFile=/etc/vault/ca.pem, got %v", opt.CACertFile)
+		}
+
+		if opt.CertFile == nil || *opt.CertFile != "/etc/vault/client.pem" {
+			t.Errorf("expected CertFile=/etc/vault/client.pem, got %v", opt.CertFile)
+		}
+
+		if opt.KeyFile == nil || *opt.KeyFile != "/etc/vault/client-key.pem" {
+			t.Errorf("expected KeyFile=/etc/vault/client-key.pem, got %v", opt.KeyFile)
+		}
+
+		if opt.ServerName == nil || *opt.ServerName != "vault.internal" {
+			t.Errorf("expected ServerName=vault.internal, got %v", opt.ServerName)
+		}
+
+		if !opt.Insecure {
+			t.Error("expected Insecure=true")
+		}
+	})
+
+	t.Run("vault-tls-skip-verify false overrides the default", func(t *testing.T) {
+		opt := MakeOptClientTls()
+		opt.Insecure = true
+
+		if err := opt.UpdateFromDockerVolume("my/secret", map[string]string{"vault-tls-skip-verify": "false"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Insecure {
+			t.Error("expected Insecure=false")
+		}
+	})
+
+	t.Run("invalid vault-tls-skip-verify returns error", func(t *testing.T) {
+		opt := MakeOptClientTls()
+
+		if err := opt.UpdateFromDockerVolume("my/secret", map[string]string{"vault-tls-skip-verify": "maybe"}); err == nil {
+			t.Error("expected error for invalid vault-tls-skip-verify")
+		}
+	})
+}
+
+func TestOptClientTlsNormalizeAndValidate(t *testing.T) {
+	t.Run("client certificate without key returns error", func(t *testing.T) {
+		certFile := "/etc/vault/client.pem"
+		opt := MakeOptClientTls()
+		opt.CertFile = &certFile
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for client certificate without key")
+		}
+	})
+
+	t.Run("empty values are normalized to nil", func(t *testing.T) {
+		empty := ""
+		opt := MakeOptClientTls()
+		opt.CACertFile = &empty
+		opt.ServerName = &empty
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.CACertFile != nil || opt.ServerName != nil {
+			t.Errorf("expected nil values, got %v and %v", opt.CACertFile, opt.ServerName)
+		}
+	})
+}
+
+func TestOptClientTlsCacheId(t *testing.T) {
+	t.Run("different tls configurations have different cache ids", func(t *testing.T) {
+		caCertFile := "/etc/vault/ca.pem"
+		serverName := "/etc/vault/ca.pem"
+
+		a := MakeOptClientTls()
+		a.CACertFile = &caCertFile
+		b := MakeOptClientTls()
+		b.ServerName = &serverName
+
+		if a.CacheId_() == b.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+
+	t.Run("nil and literal nil values have different cache ids", func(t *testing.T) {
+		nilName := "nil"
+
+		a := MakeOptClientTls()
+		b := MakeOptClientTls()
+		b.ServerName = &nilName
+
+		if a.CacheId_() == b.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+}
@@ -6,6 +6,7 @@ package options
 
 type OptDocker struct {
 	DockerVolume OptDockerVolume `json:","`
+	DockerSecret OptDockerSecret `json:","`
 	Secret       OptSecret       `json:","`
 }
 
@@ -13,6 +14,7 @@ func (z OptDocker) CacheId_() string {
 	r := ""
 
 	r += z.DockerVolume.CacheId_()
+	r += z.DockerSecret.CacheId_()
 	r += z.Secret.CacheId_()
 
 	return r
@@ -21,6 +23,7 @@ func (z OptDocker) CacheId_() string {
 func MakeOptDocker() OptDocker {
 	return OptDocker{
 		DockerVolume: MakeOptDockerVolume(),
+		DockerSecret: MakeOptDockerSecret(),
 		Secret:       MakeOptSecret(),
 	}
 }
@@ -46,6 +49,10 @@ func NewOptDockerFromDockerVolume(volumeName string, volumeOptions map[string]st
 func NewOptDockerFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string, defaultConfig *OptDocker) (*OptDocker, error) {
 	var r OptDocker
 
+	if defaultConfig != nil {
+		r = *defaultConfig
+	}
+
 	if err := r.UpdateFromDockerSecret(secretName, secretLabels, serviceLabels); err != nil {
 		return nil, err
 	}
@@ -70,6 +77,10 @@ func (z *OptDocker) UpdateFromDockerVolume(volumeName string, volumeOptions map[
 }
 
 func (z *OptDocker) UpdateFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string) error {
+	if err := z.DockerSecret.Update(secretName, secretLabels, serviceLabels); err != nil {
+		return err
+	}
+
 	if err := z.Secret.UpdateFromDockerSecret(secretName, secretLabels, serviceLabels); err != nil {
 		return err
 	}
@@ -79,6 +90,7 @@ func (z *OptDocker) UpdateFromDockerSecret(secretName string, secretLabels map[s
 
 func (z *OptDocker) Normalize() {
 	z.DockerVolume.Normalize()
+	z.DockerSecret.Normalize()
 	z.Secret.Normalize()
 }
 
@@ -89,6 +101,10 @@ func (z *OptDocker) NormalizeAndValidate() error {
 		return err
 	}
 
+	if err := z.DockerSecret.NormalizeAndValidate(); err != nil {
+		return err
+	}
+
 	if err := z.Secret.NormalizeAndValidate(); err != nil {
 		return err
 	}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	DockerSecretFormatField    = "field"
+	DockerSecretFormatJson     = "json"
+	DockerSecretFormatTemplate = "template"
+)
+
+var (
+	DockerSecretFormats = []string{
+		DockerSecretFormatField,
+		DockerSecretFormatJson,
+		DockerSecretFormatTemplate,
+	}
+)
+
+type OptDockerSecret struct {
+	Format   string  `json:","` // DockerSecretFormat*
+	Field    *string `json:","` // secret field for DockerSecretFormatField (nil means the Docker secret name)
+	Template *string `json:","` // Go template for DockerSecretFormatTemplate
+}
+
+func (z OptDockerSecret) CacheId_() string {
+	r := ""
+
+	r += z.Format
+
+	if z.Field == nil {
+		r += "nil"
+	} else {
+		r += *z.Field
+	}
+
+	if z.Template == nil {
+		r += "nil"
+	} else {
+		r += *z.Template
+	}
+
+	return r
+}
+
+// EffectiveField returns the secret field to provide for the given Docker secret name.
+func (z OptDockerSecret) EffectiveField(secretName string) string {
+	if z.Field == nil {
+		return secretName
+	}
+
+	return *z.Field
+}
+
+func MakeOptDockerSecret() OptDockerSecret {
+	return OptDockerSecret{
+		Format: DockerSecretFormatField,
+	}
+}
+
+func NewOptDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string, defaultConfig *OptDockerSecret) (*OptDockerSecret, error) {
+	var r OptDockerSecret
+
+	if defaultConfig != nil {
+		r = *defaultConfig
+	}
+
+	if err := r.Update(secretName, secretLabels, serviceLabels); err != nil {
+		return nil, err
+	}
+
+	if err := r.NormalizeAndValidate(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (z *OptDockerSecret) Update(_ string, secretLabels map[string]string, serviceLabels map[string]string) error {
+	options, err := volumeOptionsFromDockerSecretLabels(secretLabels, serviceLabels)
+	if err != nil {
+		return err
+	}
+
+	v, ok := options["format"]
+	if ok {
+		z.Format = v
+	}
+
+	of, ok := options["field"]
+	if ok {
+		z.Field = &of
+	}
+
+	ot, ok := options["template"]
+	if ok {
+		z.Template = &ot
+	}
+
+	return nil
+}
+
+func (z *OptDockerSecret) Normalize() {
+	z.Format = strings.ToLower(z.Format)
+
+	if z.Field != nil && *z.Field == "" {
+		z.Field = nil
+	}
+
+	if z.Template != nil && *z.Template == "" {
+		z.Template = nil
+	}
+}
+
+func (z *OptDockerSecret) NormalizeAndValidate() error {
+	z.Normalize()
+
+	if !slices.Contains(DockerSecretFormats, z.Format) {
+		return fmt.Errorf("unknown secret format %s", z.Format)
+	}
+
+	if z.Format == DockerSecretFormatTemplate && z.Template == nil {
+		return errors.New("template secret format requires a template to be defined")
+	}
+
+	return nil
+}
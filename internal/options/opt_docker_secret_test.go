@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"testing"
+)
+
+func TestOptDockerSecretUpdate(t *testing.T) {
+	t.Run("default format is field with the secret name as field", func(t *testing.T) {
+		opt := MakeOptDockerSecret()
+
+		if err := opt.Update("password", nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Format != DockerSecretFormatField {
+			t.Errorf("expected format %q, got %q", DockerSecretFormatField, opt.Format)
+		}
+
+		if opt.EffectiveField("password") != "password" {
+			t.Errorf("expected field %q, got %q", "password", opt.EffectiveField("password"))
+		}
+	})
+
+	t.Run("field label selects the secret field", func(t *testing.T) {
+		opt := MakeOptDockerSecret()
+
+		if err := opt.Update("app_db_password", map[string]string{"vaultfs.field": "password"}, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.EffectiveField("app_db_password") != "password" {
+			t.Errorf("expected field %q, got %q", "password", opt.EffectiveField("app_db_password"))
+		}
+	})
+
+	t.Run("service label overrides the secret label", func(t *testing.T) {
+		opt := MakeOptDockerSecret()
+
+		if err := opt.Update("secret", map[string]string{"vaultfs.format": "field"}, map[string]string{"vaultfs.format": "json"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Format != DockerSecretFormatJson {
+			t.Errorf("expected format %q, got %q", DockerSecretFormatJson, opt.Format)
+		}
+	})
+}
+
+func TestOptDockerSecretNormalizeAndValidate(t *testing.T) {
+	t.Run("format is normalized to lowercase", func(t *testing.T) {
+		opt := MakeOptDockerSecret()
+		opt.Format = "JSON"
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Format != DockerSecretFormatJson {
+			t.Errorf("expected format %q, got %q", DockerSecretFormatJson, opt.Format)
+		}
+	})
+
+	t.Run("unknown format returns error", func(t *testing.T) {
+		opt := MakeOptDockerSecret()
+		opt.Format = "xml"
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for unknown format")
+		}
+	})
+
+	t.Run("template format without template returns error", func(t *testing.T) {
+		opt := MakeOptDockerSecret()
+		opt.Format = DockerSecretFormatTemplate
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for template format without template")
+		}
+	})
+
+	t.Run("template format with template is valid", func(t *testing.T) {
+		tpl := "{{ .username }}:{{ .password }}"
+		opt := MakeOptDockerSecret()
+		opt.Format = DockerSecretFormatTemplate
+		opt.Template = &tpl
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
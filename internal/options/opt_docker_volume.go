@@ -5,13 +5,63 @@
 package options
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
 )
 
 const (
 	defaultMountMode      = 0o550
 	defaultFieldMountMode = 0o440
+
+	defaultRefreshInterval = 60
+	defaultRefreshJitter   = 10
+
+	templateVolumeOptionPrefix     = "template-"
+	templateFileVolumeOptionPrefix = "template-file-"
+
+	fieldTransformVolumeOptionPrefix = "field-transform."
+	fieldOwnerVolumeOptionPrefix     = "field-owner."
+	fieldGroupVolumeOptionPrefix     = "field-group."
+	fieldModeVolumeOptionPrefix      = "field-mode."
+)
+
+const (
+	FieldTransformBase64Decode = "base64decode"
+	FieldTransformPemSplit     = "pem-split" // one <field>-<n>.pem file per PEM block
+	FieldTransformTrim         = "trim"
+)
+
+var (
+	FieldTransforms = []string{
+		FieldTransformBase64Decode,
+		FieldTransformPemSplit,
+		FieldTransformTrim,
+	}
+)
+
+const (
+	DockerVolumeFormatJson       = "json"
+	DockerVolumeFormatEnv        = "env"
+	DockerVolumeFormatYaml       = "yaml"
+	DockerVolumeFormatProperties = "properties"
+)
+
+var (
+	DockerVolumeFormats = []string{
+		DockerVolumeFormatJson,
+		DockerVolumeFormatEnv,
+		DockerVolumeFormatYaml,
+		DockerVolumeFormatProperties,
+	}
 )
 
 type OptDockerVolume struct {
@@ -19,6 +69,26 @@ type OptDockerVolume struct {
 	MountGId       uint16 `json:","`
 	MountMode      uint32 `json:","`
 	FieldMountMode uint32 `json:","`
+
+	Templates     map[string]string `json:","` // file name -> Go template
+	TemplateFiles map[string]string `json:","` // file name -> Go template file, relative to the templates directory
+
+	Formats []string `json:","` // DockerVolumeFormat* aggregate files of all the fields
+
+	FieldTransforms map[string][]string `json:","` // field name -> FieldTransform*, applied in order
+
+	FieldsInclude []string          `json:","` // field name glob patterns, all the fields when empty
+	FieldsExclude []string          `json:","` // field name glob patterns
+	FieldMap      map[string]string `json:","` // file name -> field name
+
+	FieldUIds  map[string]uint16 `json:","` // file or field name -> owner, overriding MountUId
+	FieldGIds  map[string]uint16 `json:","` // file or field name -> group, overriding MountGId
+	FieldModes map[string]uint32 `json:","` // file or field name -> mode, overriding FieldMountMode
+
+	MetadataFiles bool `json:","` // metadata dotfiles, besides the extended attributes
+
+	RefreshInterval int `json:","` // seconds between two background refreshes, fetched on access when 0
+	RefreshJitter   int `json:","` // maximum seconds randomly added to the refresh interval
 }
 
 func (z OptDockerVolume) CacheId_() string {
@@ -29,13 +99,59 @@ func (z OptDockerVolume) CacheId_() string {
 		strconv.Itoa(int(z.MountMode)) +
 		strconv.Itoa(int(z.FieldMountMode))
 
+	for _, k := range slices.Sorted(maps.Keys(z.Templates)) {
+		r += strconv.Quote(k) + strconv.Quote(z.Templates[k])
+	}
+
+	r += ";"
+
+	for _, k := range slices.Sorted(maps.Keys(z.TemplateFiles)) {
+		r += strconv.Quote(k) + strconv.Quote(z.TemplateFiles[k])
+	}
+
+	r += ";" + strings.Join(z.Formats, ",")
+
+	for _, k := range slices.Sorted(maps.Keys(z.FieldTransforms)) {
+		r += strconv.Quote(k) + strconv.Quote(strings.Join(z.FieldTransforms[k], ","))
+	}
+
+	r += ";" + strconv.Quote(strings.Join(z.FieldsInclude, ",")) + strconv.Quote(strings.Join(z.FieldsExclude, ","))
+
+	for _, k := range slices.Sorted(maps.Keys(z.FieldMap)) {
+		r += strconv.Quote(k) + strconv.Quote(z.FieldMap[k])
+	}
+
+	r += ";"
+
+	for _, k := range slices.Sorted(maps.Keys(z.FieldUIds)) {
+		r += strconv.Quote(k) + strconv.Itoa(int(z.FieldUIds[k]))
+	}
+
+	r += ";"
+
+	for _, k := range slices.Sorted(maps.Keys(z.FieldGIds)) {
+		r += strconv.Quote(k) + strconv.Itoa(int(z.FieldGIds[k]))
+	}
+
+	r += ";"
+
+	for _, k := range slices.Sorted(maps.Keys(z.FieldModes)) {
+		r += strconv.Quote(k) + strconv.Itoa(int(z.FieldModes[k]))
+	}
+
+	r += ";" + strconv.FormatBool(z.MetadataFiles)
+
+	r += ";" + strconv.Itoa(z.RefreshInterval) + ";" + strconv.Itoa(z.RefreshJitter)
+
 	return r
 }
 
 func MakeOptDockerVolume() OptDockerVolume {
 	return OptDockerVolume{
-		MountMode:      defaultMountMode,
-		FieldMountMode: defaultFieldMountMode,
+		MountMode:       defaultMountMode,
+		FieldMountMode:  defaultFieldMountMode,
+		RefreshInterval: defaultRefreshInterval,
+		RefreshJitter:   defaultRefreshJitter,
 	}
 }
 
@@ -98,13 +214,320 @@ func (z *OptDockerVolume) Update(volumeName string, volumeOptions map[string]str
 		z.FieldMountMode = uint32(v)
 	}
 
+	v, ok := volumeOptions["formats"]
+	if ok {
+		z.Formats = util.SplitList(v)
+	}
+
+	v, ok = volumeOptions["metadata-files"]
+	if ok {
+		metadataFiles, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("convert metadata-files %s to boolean: %w", v, err)
+		}
+
+		z.MetadataFiles = metadataFiles
+	}
+
+	v, ok = volumeOptions["refresh-interval"]
+	if ok {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("convert refresh-interval %s to integer: %w", v, err)
+		}
+
+		z.RefreshInterval = i
+	}
+
+	v, ok = volumeOptions["refresh-jitter"]
+	if ok {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("convert refresh-jitter %s to integer: %w", v, err)
+		}
+
+		z.RefreshJitter = i
+	}
+
+	v, ok = volumeOptions["fields-include"]
+	if ok {
+		z.FieldsInclude = util.SplitList(v)
+	}
+
+	v, ok = volumeOptions["fields-exclude"]
+	if ok {
+		z.FieldsExclude = util.SplitList(v)
+	}
+
+	v, ok = volumeOptions["field-map"]
+	if ok {
+		z.FieldMap = map[string]string{}
+
+		for _, mapping := range util.SplitList(v) {
+			name, field, ok := strings.Cut(mapping, ":")
+			if !ok {
+				return fmt.Errorf("field-map %s must be formatted as <file>:<field>", mapping)
+			}
+
+			z.FieldMap[name] = field
+		}
+	}
+
+	var templates, templateFiles map[string]string
+	var fieldTransforms map[string][]string
+	var fieldUIds, fieldGIds map[string]uint16
+	var fieldModes map[string]uint32
+	for k, v := range volumeOptions {
+		if name, ok := strings.CutPrefix(k, fieldOwnerVolumeOptionPrefix); ok {
+			uid, err := util.UserIdFromUser(v)
+			if err != nil {
+				return fmt.Errorf("convert %s %s to user id: %w", k, v, err)
+			}
+
+			if fieldUIds == nil {
+				fieldUIds = cloneOrMakeMap(z.FieldUIds)
+			}
+
+			fieldUIds[name] = uid
+		} else if name, ok := strings.CutPrefix(k, fieldGroupVolumeOptionPrefix); ok {
+			gid, err := util.UserGroupIdFromUserGroup(v)
+			if err != nil {
+				return fmt.Errorf("convert %s %s to group id: %w", k, v, err)
+			}
+
+			if fieldGIds == nil {
+				fieldGIds = cloneOrMakeMap(z.FieldGIds)
+			}
+
+			fieldGIds[name] = gid
+		} else if name, ok := strings.CutPrefix(k, fieldModeVolumeOptionPrefix); ok {
+			mode, err := ParseFieldMode(v)
+			if err != nil {
+				return fmt.Errorf("convert %s %s to mode: %w", k, v, err)
+			}
+
+			if fieldModes == nil {
+				fieldModes = cloneOrMakeMap(z.FieldModes)
+			}
+
+			fieldModes[name] = mode
+		} else if name, ok := strings.CutPrefix(k, fieldTransformVolumeOptionPrefix); ok {
+			if fieldTransforms == nil {
+				fieldTransforms = cloneOrMakeMap(z.FieldTransforms)
+			}
+
+			fieldTransforms[name] = util.SplitList(strings.ToLower(v))
+		} else if name, ok := strings.CutPrefix(k, templateFileVolumeOptionPrefix); ok {
+			if templateFiles == nil {
+				// do not update the default config map
+				templateFiles = cloneOrMakeMap(z.TemplateFiles)
+			}
+
+			templateFiles[name] = v
+		} else if name, ok := strings.CutPrefix(k, templateVolumeOptionPrefix); ok {
+			if templates == nil {
+				templates = cloneOrMakeMap(z.Templates)
+			}
+
+			templates[name] = v
+		}
+	}
+
+	if templates != nil {
+		z.Templates = templates
+	}
+
+	if templateFiles != nil {
+		z.TemplateFiles = templateFiles
+	}
+
+	if fieldTransforms != nil {
+		z.FieldTransforms = fieldTransforms
+	}
+
+	if fieldUIds != nil {
+		z.FieldUIds = fieldUIds
+	}
+
+	if fieldGIds != nil {
+		z.FieldGIds = fieldGIds
+	}
+
+	if fieldModes != nil {
+		z.FieldModes = fieldModes
+	}
+
 	return nil
 }
 
-func (z *OptDockerVolume) Normalize() {}
+func (z *OptDockerVolume) Normalize() {
+	if z.Formats != nil {
+		formats := make([]string, 0, len(z.Formats))
+		for _, format := range z.Formats {
+			format = strings.ToLower(format)
+			if !slices.Contains(formats, format) {
+				formats = append(formats, format)
+			}
+		}
+		z.Formats = formats
+	}
+}
 
 func (z *OptDockerVolume) NormalizeAndValidate() error {
 	z.Normalize()
 
+	if z.RefreshInterval < 0 {
+		return fmt.Errorf("refresh interval %d cannot be negative", z.RefreshInterval)
+	}
+
+	if z.RefreshJitter < 0 {
+		return fmt.Errorf("refresh jitter %d cannot be negative", z.RefreshJitter)
+	}
+
+	for _, format := range z.Formats {
+		if !slices.Contains(DockerVolumeFormats, format) {
+			return fmt.Errorf("unknown volume format %s", format)
+		}
+	}
+
+	for name, transforms := range z.FieldTransforms {
+		if name == "" {
+			return errors.New("field transform name cannot be empty")
+		}
+
+		if err := ValidateFieldTransforms(transforms); err != nil {
+			return fmt.Errorf("%s field transforms: %w", name, err)
+		}
+	}
+
+	for _, pattern := range slices.Concat(z.FieldsInclude, z.FieldsExclude) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("field pattern %s: %w", pattern, err)
+		}
+	}
+
+	for name, field := range z.FieldMap {
+		if err := validateVolumeFileName(name); err != nil {
+			return fmt.Errorf("field-map: %w", err)
+		}
+
+		if field == "" {
+			return fmt.Errorf("field-map %s field cannot be empty", name)
+		}
+	}
+
+	for name, text := range z.Templates {
+		if err := validateVolumeFileName(name); err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+
+		if _, err := template.New(name).Funcs(util.TemplateFuncs()).Parse(text); err != nil {
+			return fmt.Errorf("parse %s template: %w", name, err)
+		}
+	}
+
+	for name, file := range z.TemplateFiles {
+		if err := validateVolumeFileName(name); err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+
+		if _, ok := z.Templates[name]; ok {
+			return fmt.Errorf("template %s is defined both inline and as a file", name)
+		}
+
+		if !filepath.IsLocal(file) {
+			return fmt.Errorf("template file %s must be relative to the templates directory", file)
+		}
+	}
+
+	return nil
+}
+
+// FieldNames returns the names of the files of a secret field: none when the
+// field is filtered out, the mapped names when the field is renamed.
+func (z OptDockerVolume) FieldNames(field string) []string {
+	if len(z.FieldsInclude) > 0 && !slices.ContainsFunc(z.FieldsInclude, func(pattern string) bool { return matchFieldPattern(pattern, field) }) {
+		return nil
+	}
+
+	if slices.ContainsFunc(z.FieldsExclude, func(pattern string) bool { return matchFieldPattern(pattern, field) }) {
+		return nil
+	}
+
+	r := []string{}
+	for _, name := range slices.Sorted(maps.Keys(z.FieldMap)) {
+		if z.FieldMap[name] == field {
+			r = append(r, name)
+		}
+	}
+
+	if len(r) == 0 {
+		r = append(r, field)
+	}
+
+	return r
+}
+
+func matchFieldPattern(pattern string, field string) bool {
+	ok, _ := path.Match(pattern, field)
+	return ok
+}
+
+// ParseFieldMode parses an octal file mode (eg. 0440), which can also be
+// defined in the secrets metadata.
+func ParseFieldMode(v string) (uint32, error) {
+	mode, err := strconv.ParseUint(v, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	if mode > 0o7777 {
+		return 0, fmt.Errorf("mode %s is out of range", v)
+	}
+
+	return uint32(mode), nil
+}
+
+// ValidateFieldTransforms validates a list of field transforms, which can
+// also be defined in the secrets metadata.
+func ValidateFieldTransforms(transforms []string) error {
+	for i, transform := range transforms {
+		if !slices.Contains(FieldTransforms, transform) {
+			return fmt.Errorf("unknown field transform %s", transform)
+		}
+
+		if transform == FieldTransformPemSplit && i != len(transforms)-1 {
+			return fmt.Errorf("%s field transform must be the last one", transform)
+		}
+	}
+
+	return nil
+}
+
+// validateVolumeFileName validates the name of a file added to the volume,
+// which can be nested (eg. "conf/app.ini").
+func validateVolumeFileName(name string) error {
+	if name == "" {
+		return errors.New("file name cannot be empty")
+	}
+
+	if strings.HasPrefix(name, "..") {
+		return fmt.Errorf("file name %s cannot start with ..", name)
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		if component == "" || component == "." || component == ".." {
+			return fmt.Errorf("file name %s is not a valid path", name)
+		}
+	}
+
 	return nil
 }
+
+func cloneOrMakeMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return map[string]V{}
+	}
+
+	return maps.Clone(m)
+}
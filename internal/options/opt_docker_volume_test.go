@@ -5,6 +5,7 @@
 package options
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -139,3 +140,352 @@ func TestOptDockerVolumeUpdate(t *testing.T) {
 		}
 	})
 }
+
+func TestOptDockerVolumeTemplates(t *testing.T) {
+	t.Run("template options are parsed", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+
+		if err := opt.Update("vol", map[string]string{
+			"template-jdbc.url":          "jdbc:postgresql://db/app?user={{ .username }}",
+			"template-file-conf/.pgpass": "pgpass.tpl",
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Templates["jdbc.url"] != "jdbc:postgresql://db/app?user={{ .username }}" {
+			t.Errorf("expected jdbc.url template, got %v", opt.Templates)
+		}
+
+		if opt.TemplateFiles["conf/.pgpass"] != "pgpass.tpl" {
+			t.Errorf("expected conf/.pgpass template file, got %v", opt.TemplateFiles)
+		}
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("template options do not update the default config", func(t *testing.T) {
+		defaultConfig := MakeOptDockerVolume()
+		defaultConfig.Templates = map[string]string{"a": "{{ .a }}"}
+
+		opt, err := NewOptDockerVolume("vol", map[string]string{"template-a": "{{ .b }}"}, &defaultConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Templates["a"] != "{{ .b }}" || defaultConfig.Templates["a"] != "{{ .a }}" {
+			t.Errorf("expected the default config to be left untouched, got %v and %v", opt.Templates, defaultConfig.Templates)
+		}
+	})
+
+	t.Run("invalid template returns error", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+		opt.Templates = map[string]string{"a": "{{ .a "}
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for invalid template")
+		}
+	})
+
+	t.Run("invalid template names return error", func(t *testing.T) {
+		for _, name := range []string{"..data", "a//b", "../a"} {
+			opt := MakeOptDockerVolume()
+			opt.Templates = map[string]string{name: "{{ .a }}"}
+
+			if err := opt.NormalizeAndValidate(); err == nil {
+				t.Errorf("expected error for template name %s", name)
+			}
+		}
+	})
+
+	t.Run("template file outside the templates directory returns error", func(t *testing.T) {
+		for _, file := range []string{"/etc/passwd", "../state.json"} {
+			opt := MakeOptDockerVolume()
+			opt.TemplateFiles = map[string]string{"a": file}
+
+			if err := opt.NormalizeAndValidate(); err == nil {
+				t.Errorf("expected error for template file %s", file)
+			}
+		}
+	})
+
+	t.Run("templates are part of the cache id", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+		otherOpt := MakeOptDockerVolume()
+		otherOpt.Templates = map[string]string{"a": "{{ .a }}"}
+
+		if opt.CacheId_() == otherOpt.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+}
+
+func TestOptDockerVolumeFormats(t *testing.T) {
+	t.Run("formats option is parsed and normalized", func(t *testing.T) {
+		opt, err := NewOptDockerVolume("vol", map[string]string{"formats": "JSON, env,json"}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(opt.Formats) != 2 || opt.Formats[0] != DockerVolumeFormatJson || opt.Formats[1] != DockerVolumeFormatEnv {
+			t.Errorf("expected json and env formats, got %v", opt.Formats)
+		}
+	})
+
+	t.Run("unknown format returns error", func(t *testing.T) {
+		if _, err := NewOptDockerVolume("vol", map[string]string{"formats": "xml"}, nil); err == nil {
+			t.Error("expected error for unknown format")
+		}
+	})
+}
+
+func TestOptDockerVolumeFieldTransforms(t *testing.T) {
+	t.Run("field-transform options are parsed", func(t *testing.T) {
+		opt, err := NewOptDockerVolume("vol", map[string]string{
+			"field-transform.keystore":   "Base64Decode",
+			"field-transform.bundle.pem": "trim, pem-split",
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(opt.FieldTransforms["keystore"]) != 1 || opt.FieldTransforms["keystore"][0] != FieldTransformBase64Decode {
+			t.Errorf("expected keystore base64decode transform, got %v", opt.FieldTransforms["keystore"])
+		}
+
+		if len(opt.FieldTransforms["bundle.pem"]) != 2 || opt.FieldTransforms["bundle.pem"][1] != FieldTransformPemSplit {
+			t.Errorf("expected bundle.pem trim and pem-split transforms, got %v", opt.FieldTransforms["bundle.pem"])
+		}
+	})
+
+	t.Run("unknown field transform returns error", func(t *testing.T) {
+		if _, err := NewOptDockerVolume("vol", map[string]string{"field-transform.a": "gunzip"}, nil); err == nil {
+			t.Error("expected error for unknown field transform")
+		}
+	})
+
+	t.Run("pem-split must be the last field transform", func(t *testing.T) {
+		if _, err := NewOptDockerVolume("vol", map[string]string{"field-transform.a": "pem-split,trim"}, nil); err == nil {
+			t.Error("expected error for pem-split followed by another transform")
+		}
+	})
+
+	t.Run("field transforms are part of the cache id", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+		otherOpt := MakeOptDockerVolume()
+		otherOpt.FieldTransforms = map[string][]string{"a": {FieldTransformTrim}}
+
+		if opt.CacheId_() == otherOpt.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+}
+
+func TestOptDockerVolumeFieldNames(t *testing.T) {
+	t.Run("field options are parsed", func(t *testing.T) {
+		opt, err := NewOptDockerVolume("vol", map[string]string{
+			"fields-include": "pass*, user",
+			"fields-exclude": ".*",
+			"field-map":      "DB_PASS:password, PGPASSWORD:password",
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(opt.FieldsInclude) != 2 || len(opt.FieldsExclude) != 1 || opt.FieldMap["DB_PASS"] != "password" || opt.FieldMap["PGPASSWORD"] != "password" {
+			t.Errorf("unexpected field options %v %v %v", opt.FieldsInclude, opt.FieldsExclude, opt.FieldMap)
+		}
+	})
+
+	t.Run("fields are filtered and renamed", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+		opt.FieldsInclude = []string{"pass*", "user", ".*"}
+		opt.FieldsExclude = []string{".metadata-*"}
+		opt.FieldMap = map[string]string{"DB_PASS": "password", "PGPASSWORD": "password"}
+
+		for field, expected := range map[string][]string{
+			"password":                 {"DB_PASS", "PGPASSWORD"},
+			"user":                     {"user"},
+			"host":                     nil,
+			".metadata-owner":          nil,
+			".version-metadata-author": {".version-metadata-author"},
+		} {
+			if names := opt.FieldNames(field); !slices.Equal(names, expected) {
+				t.Errorf("expected %s field names %v, got %v", field, expected, names)
+			}
+		}
+	})
+
+	t.Run("all the fields are included by default", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+
+		if names := opt.FieldNames("password"); !slices.Equal(names, []string{"password"}) {
+			t.Errorf("expected password field name, got %v", names)
+		}
+	})
+
+	t.Run("invalid field options return error", func(t *testing.T) {
+		for k, v := range map[string]string{
+			"fields-include": "[a",
+			"fields-exclude": "[a",
+			"field-map":      "password",
+		} {
+			if _, err := NewOptDockerVolume("vol", map[string]string{k: v}, nil); err == nil {
+				t.Errorf("expected error for %s=%s", k, v)
+			}
+		}
+
+		for _, name := range []string{"..data", "a//b", "../a"} {
+			opt := MakeOptDockerVolume()
+			opt.FieldMap = map[string]string{name: "password"}
+
+			if err := opt.NormalizeAndValidate(); err == nil {
+				t.Errorf("expected error for field-map name %s", name)
+			}
+		}
+	})
+
+	t.Run("field options are part of the cache id", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+		otherOpt := MakeOptDockerVolume()
+		otherOpt.FieldMap = map[string]string{"DB_PASS": "password"}
+
+		if opt.CacheId_() == otherOpt.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+}
+
+func TestOptDockerVolumeFieldAttrs(t *testing.T) {
+	t.Run("field owner, group and mode options are parsed", func(t *testing.T) {
+		opt, err := NewOptDockerVolume("vol", map[string]string{
+			"field-owner.tls.key": "70",
+			"field-group.tls.key": "root",
+			"field-mode.tls.key":  "0400",
+			"field-mode.tls.crt":  "644",
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.FieldUIds["tls.key"] != 70 || opt.FieldGIds["tls.key"] != 0 {
+			t.Errorf("expected tls.key owner 70:0, got %v %v", opt.FieldUIds, opt.FieldGIds)
+		}
+
+		if opt.FieldModes["tls.key"] != 0o400 || opt.FieldModes["tls.crt"] != 0o644 {
+			t.Errorf("expected octal modes, got %v", opt.FieldModes)
+		}
+	})
+
+	t.Run("invalid field attributes return error", func(t *testing.T) {
+		for k, v := range map[string]string{
+			"field-owner.a": "no-such-user-vaultfs",
+			"field-group.a": "no-such-group-vaultfs",
+			"field-mode.a":  "0999",
+			"field-mode.b":  "17777",
+		} {
+			if _, err := NewOptDockerVolume("vol", map[string]string{k: v}, nil); err == nil {
+				t.Errorf("expected error for %s=%s", k, v)
+			}
+		}
+	})
+
+	t.Run("field attributes do not update the default config", func(t *testing.T) {
+		defaultConfig := MakeOptDockerVolume()
+		defaultConfig.FieldModes = map[string]uint32{"a": 0o400}
+
+		opt, err := NewOptDockerVolume("vol", map[string]string{"field-mode.a": "0440"}, &defaultConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.FieldModes["a"] != 0o440 || defaultConfig.FieldModes["a"] != 0o400 {
+			t.Errorf("expected the default config to be left untouched, got %v and %v", opt.FieldModes, defaultConfig.FieldModes)
+		}
+	})
+
+	t.Run("field attributes are part of the cache id", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+		otherOpt := MakeOptDockerVolume()
+		otherOpt.FieldModes = map[string]uint32{"a": 0o400}
+
+		if opt.CacheId_() == otherOpt.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+}
+
+func TestOptDockerVolumeMetadataFiles(t *testing.T) {
+	t.Run("metadata files are disabled by default", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+
+		if opt.MetadataFiles {
+			t.Error("expected MetadataFiles=false")
+		}
+	})
+
+	t.Run("metadata-files option is parsed as boolean", func(t *testing.T) {
+		opt, err := NewOptDockerVolume("vol", map[string]string{"metadata-files": "true"}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !opt.MetadataFiles {
+			t.Error("expected MetadataFiles=true")
+		}
+	})
+
+	t.Run("invalid metadata-files returns error", func(t *testing.T) {
+		if _, err := NewOptDockerVolume("vol", map[string]string{"metadata-files": "maybe"}, nil); err == nil {
+			t.Error("expected error for invalid metadata-files")
+		}
+	})
+}
+
+func TestOptDockerVolumeRefresh(t *testing.T) {
+	t.Run("refresh defaults to every 60s with a 10s jitter", func(t *testing.T) {
+		opt := MakeOptDockerVolume()
+
+		if opt.RefreshInterval != 60 || opt.RefreshJitter != 10 {
+			t.Errorf("expected RefreshInterval=60 and RefreshJitter=10, got %d and %d", opt.RefreshInterval, opt.RefreshJitter)
+		}
+	})
+
+	t.Run("refresh options are parsed as integers", func(t *testing.T) {
+		opt, err := NewOptDockerVolume("vol", map[string]string{"refresh-interval": "0", "refresh-jitter": "5"}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.RefreshInterval != 0 || opt.RefreshJitter != 5 {
+			t.Errorf("expected RefreshInterval=0 and RefreshJitter=5, got %d and %d", opt.RefreshInterval, opt.RefreshJitter)
+		}
+	})
+
+	t.Run("invalid refresh-interval returns error", func(t *testing.T) {
+		if _, err := NewOptDockerVolume("vol", map[string]string{"refresh-interval": "1m"}, nil); err == nil {
+			t.Error("expected error for invalid refresh-interval")
+		}
+	})
+
+	t.Run("negative refresh options return error", func(t *testing.T) {
+		if _, err := NewOptDockerVolume("vol", map[string]string{"refresh-interval": "-1"}, nil); err == nil {
+			t.Error("expected error for negative refresh-interval")
+		}
+
+		if _, err := NewOptDockerVolume("vol", map[string]string{"refresh-jitter": "-1"}, nil); err == nil {
+			t.Error("expected error for negative refresh-jitter")
+		}
+	})
+
+	t.Run("refresh options are part of the cache id", func(t *testing.T) {
+		a := MakeOptDockerVolume()
+		b := MakeOptDockerVolume()
+		b.RefreshInterval = 30
+
+		if a.CacheId_() == b.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+}
@@ -4,6 +4,14 @@
 
 package options
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
 const (
 	SecretBackendVault = "vault"
 )
@@ -18,6 +26,10 @@ var (
 type OptSecret struct {
 	Backend string   `json:","`
 	Vault   OptVault `json:","`
+
+	Sources         []OptSecretSource `json:","` // secrets composing the volume (nil means the Vault secret only)
+	SourcesLayout   string            `json:","` // SecretSourcesLayout*
+	SourcesConflict string            `json:","` // SecretSourcesConflict*, with SecretSourcesLayoutMerge
 }
 
 // CacheId_ returns a unique string representing the secret config for caching purposes.
@@ -31,6 +43,16 @@ func (z OptSecret) CacheId_() string {
 		r += z.Vault.CacheId_()
 	}
 
+	if z.Sources == nil {
+		r += "nil"
+	} else {
+		for _, source := range z.Sources {
+			r += source.CacheId_()
+		}
+	}
+
+	r += z.SourcesLayout + z.SourcesConflict
+
 	return r
 }
 
@@ -39,6 +61,9 @@ func MakeOptSecret() OptSecret {
 	return OptSecret{
 		Backend: SecretBackendVault,
 		Vault:   MakeOptVault(),
+
+		SourcesLayout:   SecretSourcesLayoutSubdir,
+		SourcesConflict: SecretSourcesConflictError,
 	}
 }
 
@@ -65,6 +90,10 @@ func NewOptSecretFromDockerVolume(volumeName string, volumeOptions map[string]st
 func NewOptSecretFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string, defaultConfig *OptSecret) (*OptSecret, error) {
 	var r OptSecret
 
+	if defaultConfig != nil {
+		r = *defaultConfig
+	}
+
 	if err := r.UpdateFromDockerSecret(secretName, secretLabels, serviceLabels); err != nil {
 		return nil, err
 	}
@@ -84,6 +113,32 @@ func (z *OptSecret) UpdateFromDockerVolume(volumeName string, volumeOptions map[
 		return err
 	}
 
+	v, ok := volumeOptions["sources"]
+	if ok {
+		sources := []OptSecretSource{}
+
+		for _, spec := range util.SplitList(v) {
+			source, err := newOptSecretSource(spec, z.Vault)
+			if err != nil {
+				return err
+			}
+
+			sources = append(sources, *source)
+		}
+
+		z.Sources = sources
+	}
+
+	v, ok = volumeOptions["sources-layout"]
+	if ok {
+		z.SourcesLayout = v
+	}
+
+	v, ok = volumeOptions["sources-conflict"]
+	if ok {
+		z.SourcesConflict = v
+	}
+
 	return nil
 }
 
@@ -103,6 +158,23 @@ func (z *OptSecret) Normalize() {
 	// TODO: backend
 
 	z.Vault.Normalize()
+
+	for i := range z.Sources {
+		z.Sources[i].Normalize()
+	}
+
+	// restored from an older state file
+	if z.SourcesLayout == "" {
+		z.SourcesLayout = SecretSourcesLayoutSubdir
+	} else {
+		z.SourcesLayout = strings.ToLower(z.SourcesLayout)
+	}
+
+	if z.SourcesConflict == "" {
+		z.SourcesConflict = SecretSourcesConflictError
+	} else {
+		z.SourcesConflict = strings.ToLower(z.SourcesConflict)
+	}
 }
 
 // NormalizeAndValidate normalizes and validates the OptSecret fields.
@@ -116,5 +188,19 @@ func (z *OptSecret) NormalizeAndValidate() error {
 		}
 	}
 
+	if z.Sources != nil {
+		if err := validateSecretSources(z.Sources); err != nil {
+			return err
+		}
+	}
+
+	if !slices.Contains(SecretSourcesLayouts, z.SourcesLayout) {
+		return fmt.Errorf("unknown sources layout %s", z.SourcesLayout)
+	}
+
+	if !slices.Contains(SecretSourcesConflicts, z.SourcesConflict) {
+		return fmt.Errorf("unknown sources conflict policy %s", z.SourcesConflict)
+	}
+
 	return nil
 }
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	SecretSourcesLayoutSubdir = "subdir" // each source in its own subdirectory
+	SecretSourcesLayoutMerge  = "merge"  // all the sources fields in the volume directory
+
+	SecretSourcesConflictError = "error" // fail when two sources have the same field
+	SecretSourcesConflictFirst = "first" // keep the field of the first listed source
+	SecretSourcesConflictLast  = "last"  // keep the field of the last listed source
+)
+
+var (
+	SecretSourcesLayouts = []string{
+		SecretSourcesLayoutSubdir,
+		SecretSourcesLayoutMerge,
+	}
+
+	SecretSourcesConflicts = []string{
+		SecretSourcesConflictError,
+		SecretSourcesConflictFirst,
+		SecretSourcesConflictLast,
+	}
+)
+
+// OptSecretSource is one of the secrets composing a volume.
+type OptSecretSource struct {
+	Name  string   `json:","`
+	Vault OptVault `json:","`
+}
+
+func (z OptSecretSource) CacheId_() string {
+	return strconv.Quote(z.Name) + z.Vault.CacheId_()
+}
+
+// newOptSecretSource parses a source formatted as
+// [<name>=]<engine-type>[@<engine-mount>]:<secret>, the other options being
+// inherited from the volume ones.
+func newOptSecretSource(spec string, defaultConfig OptVault) (*OptSecretSource, error) {
+	r := OptSecretSource{
+		Vault: defaultConfig,
+	}
+
+	name, engineSpec, ok := strings.Cut(spec, "=")
+	if !ok {
+		name, engineSpec = "", spec
+	}
+
+	engine, secretPath, ok := strings.Cut(engineSpec, ":")
+	if !ok {
+		return nil, fmt.Errorf("source %s must be formatted as [<name>=]<engine-type>[@<engine-mount>]:<secret>", spec)
+	}
+
+	engineType, engineMount, ok := strings.Cut(engine, "@")
+
+	r.Vault.VaultEngine.Type = strings.ToLower(engineType)
+	if ok {
+		r.Vault.VaultEngine.MountPath = &engineMount
+	} else {
+		r.Vault.VaultEngine.MountPath = nil
+	}
+
+	// accept the Vault API paths (eg. db:creds/<role> or pki:issue/<role>)
+	switch r.Vault.VaultEngine.Type {
+	case VaultEngineTypeDb:
+		secretPath = strings.TrimPrefix(secretPath, "creds/")
+	case VaultEngineTypePki:
+		secretPath = strings.TrimPrefix(secretPath, "issue/")
+	}
+
+	r.Vault.VaultSecret.Path = secretPath
+	r.Vault.VaultSecret.KvVersion = nil
+
+	if name == "" {
+		name = path.Base(secretPath)
+	}
+
+	r.Name = name
+
+	return &r, nil
+}
+
+func (z *OptSecretSource) Normalize() {
+	z.Vault.Normalize()
+}
+
+func (z *OptSecretSource) NormalizeAndValidate() error {
+	z.Normalize()
+
+	if z.Name == "" || z.Name == "." || z.Name == ".." {
+		return fmt.Errorf("source name %q is not valid", z.Name)
+	}
+
+	if strings.HasPrefix(z.Name, ".") {
+		return fmt.Errorf("source name %s cannot start with a dot", z.Name)
+	}
+
+	if strings.Contains(z.Name, "/") {
+		return fmt.Errorf("source name %s cannot contain a slash", z.Name)
+	}
+
+	if err := z.Vault.NormalizeAndValidate(); err != nil {
+		return fmt.Errorf("source %s: %w", z.Name, err)
+	}
+
+	return nil
+}
+
+func validateSecretSources(sources []OptSecretSource) error {
+	names := map[string]bool{}
+
+	for i := range sources {
+		if err := sources[i].NormalizeAndValidate(); err != nil {
+			return err
+		}
+
+		if names[sources[i].Name] {
+			return fmt.Errorf("source name %s is not unique", sources[i].Name)
+		}
+
+		names[sources[i].Name] = true
+	}
+
+	if len(sources) == 0 {
+		return errors.New("sources cannot be empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"testing"
+)
+
+func TestOptSecretSources(t *testing.T) {
+	t.Run("sources option is parsed", func(t *testing.T) {
+		defaultConfig := MakeOptSecret()
+
+		opt, err := NewOptSecretFromDockerVolume("web", map[string]string{
+			"sources":         "kv:app/config, db:creds/readonly, cert=pki@pki_int:issue/web",
+			"auth-method":     "token",
+			"auth-token":      "s.token",
+			"pki-common-name": "web.example.com",
+		}, &defaultConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(opt.Sources) != 3 {
+			t.Fatalf("expected 3 sources, got %d", len(opt.Sources))
+		}
+
+		for i, expected := range []struct {
+			name       string
+			engineType string
+			mountPath  string
+			path       string
+		}{
+			{"config", VaultEngineTypeKv, "secret", "app/config"},
+			{"readonly", VaultEngineTypeDb, "database", "readonly"},
+			{"cert", VaultEngineTypePki, "pki_int", "web"},
+		} {
+			source := opt.Sources[i]
+
+			if source.Name != expected.name || source.Vault.VaultEngine.Type != expected.engineType || source.Vault.VaultEngine.EffectiveMountPath() != expected.mountPath || source.Vault.VaultSecret.Path != expected.path {
+				t.Errorf("expected source %+v, got %s %s %s %s", expected, source.Name, source.Vault.VaultEngine.Type, source.Vault.VaultEngine.EffectiveMountPath(), source.Vault.VaultSecret.Path)
+			}
+		}
+
+		if cn := opt.Sources[2].Vault.VaultSecret.PkiCommonName; cn == nil || *cn != "web.example.com" {
+			t.Errorf("expected the volume options to be inherited, got %v", cn)
+		}
+	})
+
+	t.Run("duplicate source names return error", func(t *testing.T) {
+		defaultConfig := MakeOptSecret()
+
+		if _, err := NewOptSecretFromDockerVolume("web", map[string]string{"auth-token": "s.token", "sources": "kv:a/config,kv:b/config"}, &defaultConfig); err == nil {
+			t.Error("expected error for duplicate source names")
+		}
+	})
+
+	t.Run("source without engine type returns error", func(t *testing.T) {
+		defaultConfig := MakeOptSecret()
+
+		if _, err := NewOptSecretFromDockerVolume("web", map[string]string{"auth-token": "s.token", "sources": "app/config"}, &defaultConfig); err == nil {
+			t.Error("expected error for source without engine type")
+		}
+	})
+
+	t.Run("invalid source name returns error", func(t *testing.T) {
+		defaultConfig := MakeOptSecret()
+
+		for _, spec := range []string{".hidden=kv:app/config", "../up=kv:app/config"} {
+			if _, err := NewOptSecretFromDockerVolume("web", map[string]string{"auth-token": "s.token", "sources": spec}, &defaultConfig); err == nil {
+				t.Errorf("expected error for source %s", spec)
+			}
+		}
+	})
+
+	t.Run("unknown sources layout returns error", func(t *testing.T) {
+		defaultConfig := MakeOptSecret()
+
+		if _, err := NewOptSecretFromDockerVolume("web", map[string]string{"auth-token": "s.token", "sources": "kv:app/config", "sources-layout": "flat"}, &defaultConfig); err == nil {
+			t.Error("expected error for unknown sources layout")
+		}
+	})
+
+	t.Run("sources are part of the cache id", func(t *testing.T) {
+		token := "s.token"
+
+		opt := MakeOptSecret()
+		opt.Vault.VaultAuth.Token = &token
+		otherOpt := opt
+		otherOpt.Sources = []OptSecretSource{}
+
+		if opt.CacheId_() == otherOpt.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+}
@@ -4,6 +4,12 @@
 
 package options
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 type OptVault struct {
 	ClientHttp  OptClientHttp  `json:","`
 	VaultAuth   OptVaultAuth   `json:","`
@@ -52,6 +58,10 @@ func NewOptVaultFromDockerVolume(volumeName string, volumeOptions map[string]str
 func NewOptVaultFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string, defaultConfig *OptVault) (*OptVault, error) {
 	var r OptVault
 
+	if defaultConfig != nil {
+		r = *defaultConfig
+	}
+
 	if err := r.UpdateFromDockerSecret(secretName, secretLabels, serviceLabels); err != nil {
 		return nil, err
 	}
@@ -64,10 +74,18 @@ func NewOptVaultFromDockerSecret(secretName string, secretLabels map[string]stri
 }
 
 func (z *OptVault) UpdateFromDockerVolume(volumeName string, volumeOptions map[string]string) error {
+	defaultAddress := z.ClientHttp.Address
+
 	if err := z.ClientHttp.UpdateFromDockerVolume(volumeName, volumeOptions); err != nil {
 		return err
 	}
 
+	if z.ClientHttp.Address != defaultAddress {
+		// the default credentials must not be sent to another Vault server, the
+		// volume has to define its own ones
+		z.VaultAuth = MakeOptVaultAuth()
+	}
+
 	if err := z.VaultAuth.UpdateFromDockerVolume(volumeName, volumeOptions); err != nil {
 		return err
 	}
@@ -129,5 +147,32 @@ func (z *OptVault) NormalizeAndValidate() error {
 		return err
 	}
 
+	if z.VaultSecret.Recursive && z.VaultEngine.Type != VaultEngineTypeKv {
+		return fmt.Errorf("recursive secrets are not supported by the %s engine", z.VaultEngine.Type)
+	}
+
 	return nil
 }
+
+// normalizeNamespace removes the leading and trailing slashes of a Vault
+// namespace, an empty namespace being the root namespace.
+func normalizeNamespace(namespace *string) *string {
+	if namespace == nil {
+		return nil
+	}
+
+	v := strings.Trim(*namespace, "/")
+	if v == "" {
+		return nil
+	}
+
+	return &v
+}
+
+func namespaceCacheId_(namespace *string) string {
+	if namespace == nil {
+		return "nil"
+	}
+
+	return strconv.Quote(*namespace)
+}
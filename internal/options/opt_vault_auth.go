@@ -31,6 +31,7 @@ var (
 type OptVaultAuth struct {
 	Method        string  `json:","` // VaultAuthMethod*
 	MountPath     *string `json:","`
+	Namespace     *string `json:","`
 	TokenRenewTtl int     `json:","`
 
 	// AppRole
@@ -72,6 +73,7 @@ func (z OptVaultAuth) CacheId_() string {
 	r := ""
 
 	r += z.EffectiveMountPath() + z.Method
+	r += namespaceCacheId_(z.Namespace)
 	r += strconv.Itoa(z.TokenRenewTtl)
 
 	switch z.Method {
@@ -137,6 +139,10 @@ func NewOptVaultAuthFromDockerVolume(volumeName string, volumeOptions map[string
 func NewOptVaultAuthFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string, defaultConfig *OptVaultAuth) (*OptVaultAuth, error) {
 	var r OptVaultAuth
 
+	if defaultConfig != nil {
+		r = *defaultConfig
+	}
+
 	if err := r.UpdateFromDockerSecret(secretName, secretLabels, serviceLabels); err != nil {
 		return nil, err
 	}
@@ -161,6 +167,15 @@ func (z *OptVaultAuth) UpdateFromDockerVolume(_ string, volumeOptions map[string
 		z.Method = v
 	}
 
+	// logins often happen in a parent namespace of the secrets engines one
+	an, ok := volumeOptions["auth-namespace"]
+	if !ok {
+		an, ok = volumeOptions["namespace"]
+	}
+	if ok {
+		z.Namespace = &an
+	}
+
 	v, ok = volumeOptions["auth-token-renew-ttl"]
 	if ok {
 		atrt, err := strconv.Atoi(v)
@@ -227,7 +242,9 @@ func (z *OptVaultAuth) UpdateFromDockerVolume(_ string, volumeOptions map[string
 }
 
 func (z *OptVaultAuth) UpdateFromDockerSecret(_ string, _ map[string]string, _ map[string]string) error {
-	return errors.New("not implemented")
+	// the auth options cannot be set from labels (cf.
+	// volumeOptionsFromDockerSecretLabels), the plugin default ones are kept
+	return nil
 }
 
 func (z *OptVaultAuth) Normalize() {
@@ -242,6 +259,8 @@ func (z *OptVaultAuth) Normalize() {
 		}
 	}
 
+	z.Namespace = normalizeNamespace(z.Namespace)
+
 	if z.RoleIdFile != nil && *z.RoleIdFile != "" {
 		f := path.Clean(*z.RoleIdFile)
 
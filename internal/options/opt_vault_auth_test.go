@@ -283,4 +283,44 @@ func TestOptVaultAuthUpdateFromDockerVolume(t *testing.T) {
 			t.Error("expected error for invalid auth-token-renew-ttl")
 		}
 	})
+
+	t.Run("namespace option sets the namespace", func(t *testing.T) {
+		opt := MakeOptVaultAuth()
+
+		if err := opt.UpdateFromDockerVolume("vol", map[string]string{"namespace": "tenant-a"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Namespace == nil || *opt.Namespace != "tenant-a" {
+			t.Errorf("expected namespace %q, got %v", "tenant-a", opt.Namespace)
+		}
+	})
+
+	t.Run("auth-namespace option takes precedence over namespace", func(t *testing.T) {
+		opt := MakeOptVaultAuth()
+
+		if err := opt.UpdateFromDockerVolume("vol", map[string]string{"namespace": "tenant-a/apps", "auth-namespace": "tenant-a"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Namespace == nil || *opt.Namespace != "tenant-a" {
+			t.Errorf("expected namespace %q, got %v", "tenant-a", opt.Namespace)
+		}
+	})
+
+	t.Run("root auth-namespace is normalized to nil", func(t *testing.T) {
+		namespace := "tenant-a"
+		opt := MakeOptVaultAuth()
+		opt.Namespace = &namespace
+
+		if err := opt.UpdateFromDockerVolume("vol", map[string]string{"auth-namespace": "/"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		opt.Normalize()
+
+		if opt.Namespace != nil {
+			t.Errorf("expected nil namespace, got %q", *opt.Namespace)
+		}
+	})
 }
@@ -16,6 +16,11 @@ const (
 	VaultEngineTypeKv  = "kv"
 	VaultEngineTypeDb  = "db"
 	VaultEngineTypePki = "pki"
+
+	// VaultEngineTypeGeneric reads (or writes) any Vault path, its alias being
+	// VaultEngineTypeLogical
+	VaultEngineTypeGeneric = "generic"
+	VaultEngineTypeLogical = "logical"
 )
 
 var (
@@ -23,6 +28,7 @@ var (
 		VaultEngineTypeKv,
 		VaultEngineTypeDb,
 		VaultEngineTypePki,
+		VaultEngineTypeGeneric,
 	}
 
 	VaultEngineDefaultMountPathFromType = map[string]string{
@@ -35,6 +41,7 @@ var (
 type OptVaultEngine struct {
 	Type      string  `json:","` // VaultEngineType*
 	MountPath *string `json:","`
+	Namespace *string `json:","`
 	KvVersion int     `json:","`
 }
 
@@ -49,6 +56,8 @@ func (z OptVaultEngine) CacheId_() string {
 		r += *z.MountPath
 	}
 
+	r += namespaceCacheId_(z.Namespace)
+
 	r += strconv.Itoa(int(z.KvVersion))
 
 	return r
@@ -124,6 +133,14 @@ func (z *OptVaultEngine) UpdateFromDockerVolume(_ string, volumeOptions map[stri
 		z.MountPath = &voem
 	}
 
+	voen, ok := volumeOptions["engine-namespace"]
+	if !ok {
+		voen, ok = volumeOptions["namespace"]
+	}
+	if ok {
+		z.Namespace = &voen
+	}
+
 	v, ok = volumeOptions["kv-engine-version"]
 	if ok {
 		i, err := strconv.Atoi(v)
@@ -137,12 +154,20 @@ func (z *OptVaultEngine) UpdateFromDockerVolume(_ string, volumeOptions map[stri
 	return nil
 }
 
-func (z *OptVaultEngine) UpdateFromDockerSecret(_ string, _ map[string]string, _ map[string]string) error {
-	return errors.New("not implemented")
+func (z *OptVaultEngine) UpdateFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string) error {
+	volumeOptions, err := volumeOptionsFromDockerSecretLabels(secretLabels, serviceLabels)
+	if err != nil {
+		return err
+	}
+
+	return z.UpdateFromDockerVolume(secretName, volumeOptions)
 }
 
 func (z *OptVaultEngine) Normalize() {
 	z.Type = strings.ToLower(z.Type)
+	if z.Type == VaultEngineTypeLogical {
+		z.Type = VaultEngineTypeGeneric
+	}
 
 	if z.MountPath != nil {
 		if *z.MountPath == "" {
@@ -152,6 +177,8 @@ func (z *OptVaultEngine) Normalize() {
 			z.MountPath = &v
 		}
 	}
+
+	z.Namespace = normalizeNamespace(z.Namespace)
 }
 
 func (z *OptVaultEngine) NormalizeAndValidate() error {
@@ -169,6 +196,7 @@ func (z *OptVaultEngine) NormalizeAndValidate() error {
 
 	case VaultEngineTypeDb:
 	case VaultEngineTypePki:
+	case VaultEngineTypeGeneric:
 	default:
 		return fmt.Errorf("unknown type %s", z.Type)
 	}
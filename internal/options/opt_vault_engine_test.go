@@ -80,6 +80,23 @@ func TestOptVaultEngineNormalizeAndValidate(t *testing.T) {
 			t.Errorf("expected type %q after normalization, got %q", VaultEngineTypeKv, opt.Type)
 		}
 	})
+
+	t.Run("logical type is normalized to generic", func(t *testing.T) {
+		opt := MakeOptVaultEngine()
+		opt.Type = "Logical"
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Type != VaultEngineTypeGeneric {
+			t.Errorf("expected type %q, got %q", VaultEngineTypeGeneric, opt.Type)
+		}
+
+		if opt.EffectiveMountPath() != "" {
+			t.Errorf("expected no default mount path, got %q", opt.EffectiveMountPath())
+		}
+	})
 }
 
 func TestOptVaultEngineEffectiveMountPath(t *testing.T) {
@@ -178,4 +195,43 @@ func TestOptVaultEngineUpdateFromDockerVolume(t *testing.T) {
 			t.Errorf("expected mount path to be cleared, got %q", *opt.MountPath)
 		}
 	})
+
+	t.Run("namespace option sets the namespace", func(t *testing.T) {
+		opt := MakeOptVaultEngine()
+
+		if err := opt.UpdateFromDockerVolume("vol", map[string]string{"namespace": "/tenant-a/"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Namespace == nil || *opt.Namespace != "tenant-a" {
+			t.Errorf("expected namespace %q, got %v", "tenant-a", opt.Namespace)
+		}
+	})
+
+	t.Run("engine-namespace option takes precedence over namespace", func(t *testing.T) {
+		opt := MakeOptVaultEngine()
+
+		if err := opt.UpdateFromDockerVolume("vol", map[string]string{"namespace": "tenant-a", "engine-namespace": "tenant-a/apps"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Namespace == nil || *opt.Namespace != "tenant-a/apps" {
+			t.Errorf("expected namespace %q, got %v", "tenant-a/apps", opt.Namespace)
+		}
+	})
+
+	t.Run("namespace is part of the cache id", func(t *testing.T) {
+		namespace := "tenant-a"
+		opt := MakeOptVaultEngine()
+		otherOpt := MakeOptVaultEngine()
+		otherOpt.Namespace = &namespace
+
+		if opt.CacheId_() == otherOpt.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
 }
@@ -7,16 +7,72 @@ package options
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/anthochamp/docker-plugin-vaultfs/internal/util"
+)
+
+const (
+	defaultPkiReissueRatio = 0.75
+
+	defaultRecursiveRefreshInterval = 60
+
+	engineParamsVolumeOptionPrefix = "engine-params-"
+)
+
+const (
+	NestedValuesJson = "json" // lists and objects are rendered as JSON
+	NestedValuesDir  = "dir"  // objects are rendered as subdirectories
+)
+
+const (
+	PkiPrivateKeyFormatPem   = "pem"   // PKCS#1 (RSA) or SEC1 (EC) PEM private key
+	PkiPrivateKeyFormatPkcs8 = "pkcs8" // PKCS#8 PEM private key
 )
 
+const (
+	PkiKeyTypeRsa     = "rsa"
+	PkiKeyTypeEc      = "ec"
+	PkiKeyTypeEd25519 = "ed25519"
+)
+
+// pkiKeyBits are the supported key sizes of each PKI key type, the first one
+// being the default.
+var pkiKeyBits = map[string][]int{
+	PkiKeyTypeRsa:     {2048, 3072, 4096},
+	PkiKeyTypeEc:      {256, 224, 384, 521},
+	PkiKeyTypeEd25519: {0},
+}
+
 type OptVaultSecret struct {
 	Path          string `json:","`
 	TokenRenewTtl int    `json:","`
 
 	KvVersion *int `json:","` // secret version for EngineKvVersion=2 (nil means "latest")
+
+	NestedValues string `json:","` // rendering of the lists and objects values (KV and generic engines)
+
+	// KV subtree
+	Recursive                bool `json:","` // Path is a prefix listed for the secrets exposed as subdirectories
+	RecursiveRefreshInterval int  `json:","` // seconds between two listings of the prefix
+
+	// PKI
+	PkiCommonName       *string  `json:","`
+	PkiAltNames         []string `json:","`
+	PkiIpSans           []string `json:","`
+	PkiTtl              *string  `json:","`
+	PkiPrivateKeyFormat *string  `json:","`
+	PkiKeyType          *string  `json:","` // the key is generated by the plugin and signed by the role when defined
+	PkiKeyBits          int      `json:","` // 0 means the default size of PkiKeyType
+	PkiReissueRatio     float64  `json:","` // fraction of the certificate TTL after which it gets re-issued
+
+	// Generic
+	EngineParams map[string]string `json:","` // request parameters, the path is written instead of read when defined
 }
 
 func (z OptVaultSecret) CacheId_() string {
@@ -30,11 +86,55 @@ func (z OptVaultSecret) CacheId_() string {
 		r += strconv.Itoa(*z.KvVersion)
 	}
 
+	r += strconv.Quote(z.NestedValues)
+
+	r += strconv.FormatBool(z.Recursive) + strconv.Itoa(z.RecursiveRefreshInterval)
+
+	for _, v := range []*string{z.PkiCommonName, z.PkiTtl, z.PkiPrivateKeyFormat, z.PkiKeyType} {
+		if v == nil {
+			r += "nil"
+		} else {
+			r += strconv.Quote(*v)
+		}
+	}
+
+	r += strconv.Itoa(z.PkiKeyBits) + ";"
+
+	r += strconv.Itoa(len(z.PkiAltNames))
+	for _, v := range z.PkiAltNames {
+		r += strconv.Quote(v)
+	}
+
+	r += strconv.Itoa(len(z.PkiIpSans))
+	for _, v := range z.PkiIpSans {
+		r += strconv.Quote(v)
+	}
+
+	r += strconv.FormatFloat(z.PkiReissueRatio, 'f', -1, 64)
+
+	for _, k := range slices.Sorted(maps.Keys(z.EngineParams)) {
+		r += strconv.Quote(k) + strconv.Quote(z.EngineParams[k])
+	}
+
 	return r
 }
 
+// EffectivePkiReissueRatio returns the PKI re-issue ratio, falling back to
+// the default one when unset (eg. restored from an older state file).
+func (z OptVaultSecret) EffectivePkiReissueRatio() float64 {
+	if z.PkiReissueRatio <= 0 {
+		return defaultPkiReissueRatio
+	}
+
+	return z.PkiReissueRatio
+}
+
 func MakeOptVaultSecret() OptVaultSecret {
-	return OptVaultSecret{}
+	return OptVaultSecret{
+		NestedValues:             NestedValuesJson,
+		RecursiveRefreshInterval: defaultRecursiveRefreshInterval,
+		PkiReissueRatio:          defaultPkiReissueRatio,
+	}
 }
 
 func NewOptVaultSecretFromDockerVolume(volumeName string, volumeOptions map[string]string, defaultConfig *OptVaultSecret) (*OptVaultSecret, error) {
@@ -87,6 +187,11 @@ func (z *OptVaultSecret) UpdateFromDockerVolume(volumeName string, volumeOptions
 	vns := strings.SplitN(volumeName, "@", 2)
 
 	vos, ok := volumeOptions["secret"]
+	if !ok {
+		// deprecated alias of the secret option for the database engines
+		vos, ok = volumeOptions["db-role"]
+	}
+
 	if ok {
 		z.Path = vos
 	} else {
@@ -116,11 +221,107 @@ func (z *OptVaultSecret) UpdateFromDockerVolume(volumeName string, volumeOptions
 		}
 	}
 
+	v, ok = volumeOptions["nested-values"]
+	if ok {
+		z.NestedValues = v
+	}
+
+	v, ok = volumeOptions["secret-recursive"]
+	if ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("convert secret-recursive %s to boolean: %w", v, err)
+		}
+
+		z.Recursive = b
+	}
+
+	v, ok = volumeOptions["secret-recursive-refresh-interval"]
+	if ok {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("unable to convert secret-recursive-refresh-interval value %s to integer", v)
+		}
+
+		z.RecursiveRefreshInterval = i
+	}
+
+	vopcn, ok := volumeOptions["pki-common-name"]
+	if ok {
+		z.PkiCommonName = &vopcn
+	}
+
+	v, ok = volumeOptions["pki-alt-names"]
+	if ok {
+		z.PkiAltNames = util.SplitList(v)
+	}
+
+	v, ok = volumeOptions["pki-ip-sans"]
+	if ok {
+		z.PkiIpSans = util.SplitList(v)
+	}
+
+	vopt, ok := volumeOptions["pki-ttl"]
+	if ok {
+		z.PkiTtl = &vopt
+	}
+
+	vopkf, ok := volumeOptions["pki-private-key-format"]
+	if ok {
+		z.PkiPrivateKeyFormat = &vopkf
+	}
+
+	vopkt, ok := volumeOptions["pki-key-type"]
+	if ok {
+		z.PkiKeyType = &vopkt
+	}
+
+	v, ok = volumeOptions["pki-key-bits"]
+	if ok {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("unable to convert pki-key-bits value %s to integer", v)
+		}
+
+		z.PkiKeyBits = i
+	}
+
+	v, ok = volumeOptions["pki-reissue-ratio"]
+	if ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("convert pki-reissue-ratio %s to float: %w", v, err)
+		}
+
+		z.PkiReissueRatio = f
+	}
+
+	var engineParams map[string]string
+	for k, v := range volumeOptions {
+		if name, ok := strings.CutPrefix(k, engineParamsVolumeOptionPrefix); ok {
+			if engineParams == nil {
+				// do not update the default config map
+				engineParams = cloneOrMakeMap(z.EngineParams)
+			}
+
+			engineParams[name] = v
+		}
+	}
+
+	if engineParams != nil {
+		z.EngineParams = engineParams
+	}
+
 	return nil
 }
 
-func (z *OptVaultSecret) UpdateFromDockerSecret(_ string, _ map[string]string, _ map[string]string) error {
-	return errors.New("not implemented")
+func (z *OptVaultSecret) UpdateFromDockerSecret(secretName string, secretLabels map[string]string, serviceLabels map[string]string) error {
+	volumeOptions, err := volumeOptionsFromDockerSecretLabels(secretLabels, serviceLabels)
+	if err != nil {
+		return err
+	}
+
+	return z.UpdateFromDockerVolume(secretName, volumeOptions)
 }
 
 func (z *OptVaultSecret) NormalizeAndValidate() error {
@@ -130,6 +331,60 @@ func (z *OptVaultSecret) NormalizeAndValidate() error {
 		return errors.New("path cannot be empty")
 	}
 
+	switch z.NestedValues {
+	case NestedValuesJson, NestedValuesDir:
+	default:
+		return fmt.Errorf("nested values rendering %s is not supported", z.NestedValues)
+	}
+
+	if z.Recursive {
+		if z.KvVersion != nil {
+			return errors.New("KV secret version cannot be set on a recursive secret")
+		}
+
+		if z.RecursiveRefreshInterval <= 0 {
+			return fmt.Errorf("recursive secret refresh interval %d must be positive", z.RecursiveRefreshInterval)
+		}
+	}
+
+	if z.PkiReissueRatio <= 0 || z.PkiReissueRatio > 1 {
+		return fmt.Errorf("PKI re-issue ratio %v must be within ]0, 1]", z.PkiReissueRatio)
+	}
+
+	// the private key is always written PEM-encoded to private_key.pem
+	if z.PkiPrivateKeyFormat != nil {
+		switch *z.PkiPrivateKeyFormat {
+		case PkiPrivateKeyFormatPem, PkiPrivateKeyFormatPkcs8:
+		default:
+			return fmt.Errorf("PKI private key format %s is not supported, only %s and %s are", *z.PkiPrivateKeyFormat, PkiPrivateKeyFormatPem, PkiPrivateKeyFormatPkcs8)
+		}
+	}
+
+	if z.PkiKeyType != nil {
+		keyBits, ok := pkiKeyBits[*z.PkiKeyType]
+		if !ok {
+			return fmt.Errorf("PKI key type %s is not supported, only %s, %s and %s are", *z.PkiKeyType, PkiKeyTypeRsa, PkiKeyTypeEc, PkiKeyTypeEd25519)
+		}
+
+		if z.PkiKeyBits != 0 && !slices.Contains(keyBits, z.PkiKeyBits) {
+			return fmt.Errorf("PKI key size %d is not supported by the %s key type", z.PkiKeyBits, *z.PkiKeyType)
+		}
+	} else if z.PkiKeyBits != 0 {
+		return errors.New("PKI key size requires a PKI key type")
+	}
+
+	for _, ip := range z.PkiIpSans {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("PKI IP SAN %s is not a valid IP address", ip)
+		}
+	}
+
+	for k := range z.EngineParams {
+		if k == "" {
+			return errors.New("engine parameter name cannot be empty")
+		}
+	}
+
 	return nil
 }
 
@@ -137,4 +392,47 @@ func (z *OptVaultSecret) Normalize() {
 	if z.Path != "" {
 		z.Path = path.Clean(z.Path)
 	}
+
+	// restored from an older state file
+	if z.NestedValues == "" {
+		z.NestedValues = NestedValuesJson
+	} else {
+		z.NestedValues = strings.ToLower(z.NestedValues)
+	}
+
+	if z.PkiCommonName != nil && *z.PkiCommonName == "" {
+		z.PkiCommonName = nil
+	}
+
+	if z.PkiTtl != nil && *z.PkiTtl == "" {
+		z.PkiTtl = nil
+	}
+
+	if z.PkiPrivateKeyFormat != nil {
+		if *z.PkiPrivateKeyFormat == "" {
+			z.PkiPrivateKeyFormat = nil
+		} else {
+			v := strings.ToLower(*z.PkiPrivateKeyFormat)
+			z.PkiPrivateKeyFormat = &v
+		}
+	}
+
+	if z.PkiKeyType != nil {
+		if *z.PkiKeyType == "" {
+			z.PkiKeyType = nil
+		} else {
+			v := strings.ToLower(*z.PkiKeyType)
+			z.PkiKeyType = &v
+		}
+	}
+}
+
+// EffectivePkiKeyBits returns the PKI key size, falling back to the default
+// size of the PKI key type when unset.
+func (z OptVaultSecret) EffectivePkiKeyBits() int {
+	if z.PkiKeyBits != 0 || z.PkiKeyType == nil {
+		return z.PkiKeyBits
+	}
+
+	return pkiKeyBits[*z.PkiKeyType][0]
 }
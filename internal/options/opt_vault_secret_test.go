@@ -33,6 +33,30 @@ func TestOptVaultSecretUpdateFromDockerVolume(t *testing.T) {
 		}
 	})
 
+	t.Run("deprecated db-role option is used as path", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("ignored-name", map[string]string{"db-role": "readonly"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Path != "readonly" {
+			t.Errorf("expected path %q, got %q", "readonly", opt.Path)
+		}
+	})
+
+	t.Run("secret option takes precedence over db-role", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("ignored-name", map[string]string{"secret": "readwrite", "db-role": "readonly"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.Path != "readwrite" {
+			t.Errorf("expected path %q, got %q", "readwrite", opt.Path)
+		}
+	})
+
 	t.Run("at-sign suffix sets KV version", func(t *testing.T) {
 		opt := MakeOptVaultSecret()
 
@@ -125,6 +149,142 @@ func TestOptVaultSecretUpdateFromDockerVolume(t *testing.T) {
 		}
 	})
 
+	t.Run("pki options are parsed", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("web", map[string]string{
+			"pki-common-name":        "web.example.com",
+			"pki-alt-names":          "www.example.com, api.example.com",
+			"pki-ip-sans":            "10.0.0.1",
+			"pki-ttl":                "72h",
+			"pki-private-key-format": "PKCS8",
+			"pki-reissue-ratio":      "0.5",
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.PkiCommonName == nil || *opt.PkiCommonName != "web.example.com" {
+			t.Errorf("expected PkiCommonName=web.example.com, got %v", opt.PkiCommonName)
+		}
+
+		if len(opt.PkiAltNames) != 2 || opt.PkiAltNames[0] != "www.example.com" || opt.PkiAltNames[1] != "api.example.com" {
+			t.Errorf("expected two alt names, got %v", opt.PkiAltNames)
+		}
+
+		if len(opt.PkiIpSans) != 1 || opt.PkiIpSans[0] != "10.0.0.1" {
+			t.Errorf("expected one IP SAN, got %v", opt.PkiIpSans)
+		}
+
+		if opt.PkiTtl == nil || *opt.PkiTtl != "72h" {
+			t.Errorf("expected PkiTtl=72h, got %v", opt.PkiTtl)
+		}
+
+		if opt.PkiReissueRatio != 0.5 {
+			t.Errorf("expected PkiReissueRatio=0.5, got %v", opt.PkiReissueRatio)
+		}
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.PkiPrivateKeyFormat == nil || *opt.PkiPrivateKeyFormat != "pkcs8" {
+			t.Errorf("expected PkiPrivateKeyFormat=pkcs8 after normalization, got %v", opt.PkiPrivateKeyFormat)
+		}
+	})
+
+	t.Run("pem pki-private-key-format is valid", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("web", map[string]string{"pki-private-key-format": "pem"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("der pki-private-key-format returns error", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("web", map[string]string{"pki-private-key-format": "der"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for der pki-private-key-format")
+		}
+	})
+
+	t.Run("pki-key-type options are parsed", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("web", map[string]string{"pki-key-type": "EC", "pki-key-bits": "384"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.PkiKeyType == nil || *opt.PkiKeyType != PkiKeyTypeEc || opt.EffectivePkiKeyBits() != 384 {
+			t.Errorf("expected an ec 384 bits key, got %v and %d", opt.PkiKeyType, opt.EffectivePkiKeyBits())
+		}
+	})
+
+	t.Run("pki-key-type defines the default key size", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("web", map[string]string{"pki-key-type": "rsa"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.EffectivePkiKeyBits() != 2048 {
+			t.Errorf("expected a 2048 bits key, got %d", opt.EffectivePkiKeyBits())
+		}
+	})
+
+	t.Run("invalid pki key options return error", func(t *testing.T) {
+		for _, volumeOptions := range []map[string]string{
+			{"pki-key-type": "dsa"},
+			{"pki-key-type": "ec", "pki-key-bits": "2048"},
+			{"pki-key-type": "ed25519", "pki-key-bits": "256"},
+			{"pki-key-bits": "2048"},
+		} {
+			opt := MakeOptVaultSecret()
+
+			if err := opt.UpdateFromDockerVolume("web", volumeOptions); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := opt.NormalizeAndValidate(); err == nil {
+				t.Errorf("expected error for %v", volumeOptions)
+			}
+		}
+	})
+
+	t.Run("invalid pki-key-bits returns error", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("web", map[string]string{"pki-key-bits": "large"}); err == nil {
+			t.Error("expected error for invalid pki-key-bits")
+		}
+	})
+
+	t.Run("invalid pki-reissue-ratio returns error", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		err := opt.UpdateFromDockerVolume("web", map[string]string{"pki-reissue-ratio": "not-a-number"})
+
+		if err == nil {
+			t.Error("expected error for invalid pki-reissue-ratio")
+		}
+	})
+
 	t.Run("invalid kv-secret-version returns error", func(t *testing.T) {
 		opt := MakeOptVaultSecret()
 
@@ -134,6 +294,170 @@ func TestOptVaultSecretUpdateFromDockerVolume(t *testing.T) {
 			t.Error("expected error for invalid kv-secret-version")
 		}
 	})
+
+	t.Run("engine-params options are parsed", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("creds/deploy", map[string]string{
+			"engine-params-ttl":      "15m",
+			"engine-params-role_arn": "arn:aws:iam::123456789012:role/deploy",
+			"engine-mount":           "aws",
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(opt.EngineParams) != 2 || opt.EngineParams["ttl"] != "15m" || opt.EngineParams["role_arn"] != "arn:aws:iam::123456789012:role/deploy" {
+			t.Errorf("expected two engine params, got %v", opt.EngineParams)
+		}
+	})
+
+	t.Run("engine-params options do not update the default config", func(t *testing.T) {
+		defaultConfig := MakeOptVaultSecret()
+		defaultConfig.EngineParams = map[string]string{"ttl": "1h"}
+
+		opt, err := NewOptVaultSecretFromDockerVolume("creds/deploy", map[string]string{"engine-params-ttl": "15m"}, &defaultConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.EngineParams["ttl"] != "15m" {
+			t.Errorf("expected ttl=15m, got %v", opt.EngineParams["ttl"])
+		}
+
+		if defaultConfig.EngineParams["ttl"] != "1h" {
+			t.Errorf("expected default ttl=1h, got %v", defaultConfig.EngineParams["ttl"])
+		}
+	})
+
+	t.Run("nested-values option is parsed", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("app", map[string]string{"nested-values": "dir"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.NestedValues != NestedValuesDir {
+			t.Errorf("expected nested values %q, got %q", NestedValuesDir, opt.NestedValues)
+		}
+	})
+
+	t.Run("nested values rendering is part of the cache id", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+		otherOpt := MakeOptVaultSecret()
+		otherOpt.NestedValues = NestedValuesDir
+
+		if opt.CacheId_() == otherOpt.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
+
+	t.Run("pki options do not collide in the cache id", func(t *testing.T) {
+		strPtr := func(v string) *string { return &v }
+
+		for _, tc := range []struct {
+			name        string
+			update      func(opt *OptVaultSecret)
+			otherUpdate func(opt *OptVaultSecret)
+		}{
+			{
+				name: "common name and ttl",
+				update: func(opt *OptVaultSecret) {
+					opt.PkiCommonName, opt.PkiTtl = strPtr("a"), strPtr("1h0")
+				},
+				otherUpdate: func(opt *OptVaultSecret) {
+					opt.PkiCommonName, opt.PkiTtl = strPtr("a1h"), strPtr("0")
+				},
+			},
+			{
+				name: "nil and nil named common name",
+				update: func(opt *OptVaultSecret) {
+					opt.PkiCommonName = nil
+				},
+				otherUpdate: func(opt *OptVaultSecret) {
+					opt.PkiCommonName = strPtr("nil")
+				},
+			},
+			{
+				name: "alt names and ip sans",
+				update: func(opt *OptVaultSecret) {
+					opt.PkiAltNames, opt.PkiIpSans = []string{"a.example.com", "b.example.com"}, nil
+				},
+				otherUpdate: func(opt *OptVaultSecret) {
+					opt.PkiAltNames, opt.PkiIpSans = []string{"a.example.com,b.example.com"}, nil
+				},
+			},
+			{
+				name: "alt names moved to ip sans",
+				update: func(opt *OptVaultSecret) {
+					opt.PkiAltNames, opt.PkiIpSans = []string{"a;b"}, nil
+				},
+				otherUpdate: func(opt *OptVaultSecret) {
+					opt.PkiAltNames, opt.PkiIpSans = []string{"a"}, []string{"b"}
+				},
+			},
+			{
+				name: "key type and size",
+				update: func(opt *OptVaultSecret) {
+					opt.PkiKeyType, opt.PkiKeyBits = strPtr("ec"), 384
+				},
+				otherUpdate: func(opt *OptVaultSecret) {
+					opt.PkiKeyType, opt.PkiKeyBits = strPtr("ec"), 256
+				},
+			},
+			{
+				name: "ttl and private key format",
+				update: func(opt *OptVaultSecret) {
+					opt.PkiTtl, opt.PkiPrivateKeyFormat = strPtr("1hpkcs8"), nil
+				},
+				otherUpdate: func(opt *OptVaultSecret) {
+					opt.PkiTtl, opt.PkiPrivateKeyFormat = strPtr("1h"), strPtr("pkcs8")
+				},
+			},
+		} {
+			opt := MakeOptVaultSecret()
+			tc.update(&opt)
+
+			otherOpt := MakeOptVaultSecret()
+			tc.otherUpdate(&otherOpt)
+
+			if opt.CacheId_() == otherOpt.CacheId_() {
+				t.Errorf("%s: expected different cache ids, got %q", tc.name, opt.CacheId_())
+			}
+		}
+	})
+
+	t.Run("secret-recursive options are parsed", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("app/prod", map[string]string{
+			"secret-recursive":                  "true",
+			"secret-recursive-refresh-interval": "300",
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !opt.Recursive || opt.RecursiveRefreshInterval != 300 {
+			t.Errorf("expected recursive secret refreshed every 300s, got %v and %d", opt.Recursive, opt.RecursiveRefreshInterval)
+		}
+	})
+
+	t.Run("invalid secret-recursive returns error", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+
+		if err := opt.UpdateFromDockerVolume("app/prod", map[string]string{"secret-recursive": "maybe"}); err == nil {
+			t.Error("expected error for invalid secret-recursive")
+		}
+	})
+
+	t.Run("engine params are part of the cache id", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+		otherOpt := MakeOptVaultSecret()
+		otherOpt.EngineParams = map[string]string{"ttl": "15m"}
+
+		if opt.CacheId_() == otherOpt.CacheId_() {
+			t.Error("expected different cache ids")
+		}
+	})
 }
 
 func TestOptVaultSecretNormalizeAndValidate(t *testing.T) {
@@ -168,4 +492,73 @@ func TestOptVaultSecretNormalizeAndValidate(t *testing.T) {
 			t.Errorf("expected cleaned path %q, got %q", "some/secret", opt.Path)
 		}
 	})
+
+	t.Run("pki re-issue ratio out of range returns error", func(t *testing.T) {
+		for _, ratio := range []float64{-1, 0, 1.5} {
+			opt := MakeOptVaultSecret()
+			opt.Path = "web"
+			opt.PkiReissueRatio = ratio
+
+			if err := opt.NormalizeAndValidate(); err == nil {
+				t.Errorf("expected error for pki re-issue ratio %v", ratio)
+			}
+		}
+	})
+
+	t.Run("invalid pki IP SAN returns error", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+		opt.Path = "web"
+		opt.PkiIpSans = []string{"not-an-ip"}
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for invalid pki IP SAN")
+		}
+	})
+
+	t.Run("unsupported nested values rendering returns error", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+		opt.Path = "web"
+		opt.NestedValues = "yaml"
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for unsupported nested values rendering")
+		}
+	})
+
+	t.Run("empty nested values rendering defaults to json", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+		opt.Path = "web"
+		opt.NestedValues = ""
+
+		if err := opt.NormalizeAndValidate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.NestedValues != NestedValuesJson {
+			t.Errorf("expected nested values %q, got %q", NestedValuesJson, opt.NestedValues)
+		}
+	})
+
+	t.Run("recursive secret with a KV version returns error", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+		opt.Path = "app/prod"
+		opt.Recursive = true
+		version := 2
+		opt.KvVersion = &version
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for recursive secret with a KV version")
+		}
+	})
+
+	t.Run("recursive secret with a non-positive refresh interval returns error", func(t *testing.T) {
+		opt := MakeOptVaultSecret()
+		opt.Path = "app/prod"
+		opt.Recursive = true
+		opt.RecursiveRefreshInterval = 0
+
+		if err := opt.NormalizeAndValidate(); err == nil {
+			t.Error("expected error for non-positive refresh interval")
+		}
+	})
 }
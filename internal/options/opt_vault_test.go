@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package options
+
+import (
+	"testing"
+)
+
+func TestOptVaultUpdateFromDockerVolume(t *testing.T) {
+	newDefaultConfig := func() OptVault {
+		token := "default-token"
+
+		r := MakeOptVault()
+		r.ClientHttp.Address = "https://vault.example.com:8200"
+		r.VaultAuth.Token = &token
+
+		return r
+	}
+
+	t.Run("default credentials are used with the default vault-url", func(t *testing.T) {
+		defaultConfig := newDefaultConfig()
+
+		opt, err := NewOptVaultFromDockerVolume("app", map[string]string{"vault-url": "https://vault.example.com:8200"}, &defaultConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.VaultAuth.Token == nil || *opt.VaultAuth.Token != "default-token" {
+			t.Errorf("expected default token, got %v", opt.VaultAuth.Token)
+		}
+	})
+
+	t.Run("other vault-url without credentials returns error", func(t *testing.T) {
+		defaultConfig := newDefaultConfig()
+
+		if _, err := NewOptVaultFromDockerVolume("app", map[string]string{"vault-url": "https://staging.example.com:8200"}, &defaultConfig); err == nil {
+			t.Error("expected error for another vault-url without credentials")
+		}
+	})
+
+	t.Run("other vault-url does not inherit the default credentials", func(t *testing.T) {
+		defaultConfig := newDefaultConfig()
+		roleId := "default-role-id"
+		secretId := "default-secret-id"
+		defaultConfig.VaultAuth.Method = VaultAuthMethodAppRole
+		defaultConfig.VaultAuth.RoleId = &roleId
+		defaultConfig.VaultAuth.SecretId = &secretId
+
+		if _, err := NewOptVaultFromDockerVolume("app", map[string]string{
+			"vault-url":    "https://staging.example.com:8200",
+			"auth-method":  "approle",
+			"auth-role-id": "staging-role-id",
+		}, &defaultConfig); err == nil {
+			t.Error("expected error for another vault-url without its own secret id")
+		}
+	})
+
+	t.Run("other vault-url with its own credentials is valid", func(t *testing.T) {
+		defaultConfig := newDefaultConfig()
+
+		opt, err := NewOptVaultFromDockerVolume("app", map[string]string{
+			"vault-url":  "https://staging.example.com:8200",
+			"auth-token": "staging-token",
+		}, &defaultConfig)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if opt.VaultAuth.Token == nil || *opt.VaultAuth.Token != "staging-token" {
+			t.Errorf("expected staging token, got %v", opt.VaultAuth.Token)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// AesKeySize is the size of the AES-256 keys.
+	AesKeySize = 32
+)
+
+// NewAesKey returns a new random AES-256 key.
+func NewAesKey() ([]byte, error) {
+	key := make([]byte, AesKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// EncryptAesGcm encrypts the plaintext with AES-GCM, returning the random nonce followed by the ciphertext.
+// The additional data is authenticated but not encrypted, and must be given back to DecryptAesGcm.
+func EncryptAesGcm(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAesGcm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// DecryptAesGcm decrypts data produced by EncryptAesGcm.
+func DecryptAesGcm(key []byte, data []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAesGcm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additionalData)
+}
+
+func newAesGcm(key []byte) (cipher.AEAD, error) {
+	if len(key) != AesKeySize {
+		return nil, fmt.Errorf("key must be %d bytes long", AesKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// AesKeyFromPassphrase derives an AES-256 key from a passphrase using PBKDF2-SHA256.
+func AesKeyFromPassphrase(passphrase string, salt []byte, iterations int) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase cannot be empty")
+	}
+
+	return pbkdf2.Key(sha256.New, passphrase, salt, iterations, AesKeySize)
+}
+
+// AesKeyFromFile reads an AES-256 key from a file containing either the raw
+// key, or its hexadecimal or base64 encoding.
+func AesKeyFromFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) == AesKeySize {
+		return content, nil
+	}
+
+	content = bytes.TrimSpace(content)
+
+	if key, err := hex.DecodeString(string(content)); err == nil && len(key) == AesKeySize {
+		return key, nil
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(string(content)); err == nil && len(key) == AesKeySize {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("key file must contain a %d bytes key (raw, hexadecimal or base64 encoded)", AesKeySize)
+}
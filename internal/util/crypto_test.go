@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestEncryptAesGcm(t *testing.T) {
+	t.Run("decrypts what it encrypts", func(t *testing.T) {
+		key, err := NewAesKey()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		plaintext := []byte("super secret state")
+
+		ciphertext, err := EncryptAesGcm(key, plaintext, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if bytes.Contains(ciphertext, plaintext) {
+			t.Error("expected ciphertext not to contain the plaintext")
+		}
+
+		result, err := DecryptAesGcm(key, ciphertext, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(result, plaintext) {
+			t.Errorf("expected %q, got %q", plaintext, result)
+		}
+	})
+
+	t.Run("wrong key fails to decrypt", func(t *testing.T) {
+		key, _ := NewAesKey()
+		otherKey, _ := NewAesKey()
+
+		ciphertext, err := EncryptAesGcm(key, []byte("data"), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := DecryptAesGcm(otherKey, ciphertext, nil); err == nil {
+			t.Error("expected error when decrypting with the wrong key")
+		}
+	})
+
+	t.Run("different additional data fails to decrypt", func(t *testing.T) {
+		key, _ := NewAesKey()
+
+		ciphertext, err := EncryptAesGcm(key, []byte("data"), []byte("header"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := DecryptAesGcm(key, ciphertext, []byte("other header")); err == nil {
+			t.Error("expected error when decrypting with different additional data")
+		}
+
+		if _, err := DecryptAesGcm(key, ciphertext, []byte("header")); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("invalid key size returns error", func(t *testing.T) {
+		if _, err := EncryptAesGcm([]byte("short"), []byte("data"), nil); err == nil {
+			t.Error("expected error for invalid key size")
+		}
+	})
+}
+
+func TestAesKeyFromPassphrase(t *testing.T) {
+	t.Run("same passphrase and salt derive the same key", func(t *testing.T) {
+		salt := []byte("0123456789abcdef")
+
+		first, err := AesKeyFromPassphrase("passphrase", salt, 1000)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		second, err := AesKeyFromPassphrase("passphrase", salt, 1000)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(first, second) || len(first) != AesKeySize {
+			t.Error("expected identical keys of AES-256 size")
+		}
+	})
+
+	t.Run("empty passphrase returns error", func(t *testing.T) {
+		if _, err := AesKeyFromPassphrase("", []byte("salt"), 1000); err == nil {
+			t.Error("expected error for empty passphrase")
+		}
+	})
+}
+
+func TestAesKeyFromFile(t *testing.T) {
+	key, _ := NewAesKey()
+	dir := t.TempDir()
+
+	tests := map[string][]byte{
+		"raw":    key,
+		"hex":    []byte(hex.EncodeToString(key) + "\n"),
+		"base64": []byte(base64.StdEncoding.EncodeToString(key) + "\n"),
+	}
+
+	for name, content := range tests {
+		t.Run(name+" key is read", func(t *testing.T) {
+			filePath := path.Join(dir, name)
+			if err := os.WriteFile(filePath, content, 0o600); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := AesKeyFromFile(filePath)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !bytes.Equal(result, key) {
+				t.Error("expected the key from the file")
+			}
+		})
+	}
+
+	t.Run("invalid key returns error", func(t *testing.T) {
+		filePath := path.Join(dir, "invalid")
+		if err := os.WriteFile(filePath, []byte("not a key"), 0o600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := AesKeyFromFile(filePath); err == nil {
+			t.Error("expected error for invalid key file")
+		}
+	})
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// EncodeDotEnv encodes a map as shell-escaped KEY='value' lines, the
+// characters of the keys which are not allowed in a variable name being
+// replaced by an underscore (the first key in the sorted order wins).
+func EncodeDotEnv(m map[string]string) []byte {
+	var buf bytes.Buffer
+
+	names := map[string]bool{}
+
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		name := dotEnvName(k)
+		if names[name] {
+			continue
+		}
+		names[name] = true
+
+		buf.WriteString(name + "='" + strings.ReplaceAll(m[k], "'", `'\''`) + "'\n")
+	}
+
+	return buf.Bytes()
+}
+
+func dotEnvName(k string) string {
+	r := []byte(k)
+
+	for i, c := range r {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			r[i] = '_'
+		}
+	}
+
+	if len(r) == 0 || (r[0] >= '0' && r[0] <= '9') {
+		return "_" + string(r)
+	}
+
+	return string(r)
+}
+
+// EncodeYaml encodes a map as a YAML mapping of double-quoted strings.
+func EncodeYaml(m map[string]string) []byte {
+	var buf bytes.Buffer
+
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		buf.WriteString(yamlQuote(k) + ": " + yamlQuote(m[k]) + "\n")
+	}
+
+	return buf.Bytes()
+}
+
+// yamlQuote returns a YAML double-quoted scalar, the characters which are
+// not printable in YAML (eg. DEL, C1 controls) and the line separators being
+// escaped.
+func yamlQuote(s string) string {
+	var buf strings.Builder
+
+	buf.WriteByte('"')
+
+	for _, c := range s {
+		switch {
+		case c == '"':
+			buf.WriteString(`\"`)
+		case c == '\\':
+			buf.WriteString(`\\`)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c >= 0x20 && c <= 0x7e, c >= 0xa0 && c <= 0xd7ff && c != 0x2028 && c != 0x2029,
+			c >= 0xe000 && c <= 0xfffd && c != 0xfeff, c >= 0x10000:
+			buf.WriteRune(c)
+		case c <= 0xff:
+			fmt.Fprintf(&buf, `\x%02x`, c)
+		default:
+			fmt.Fprintf(&buf, `\u%04x`, c)
+		}
+	}
+
+	buf.WriteByte('"')
+
+	return buf.String()
+}
+
+// EncodeProperties encodes a map as a Java properties file.
+func EncodeProperties(m map[string]string) []byte {
+	var buf bytes.Buffer
+
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		buf.WriteString(propertiesEscape(k, true) + "=" + propertiesEscape(m[k], false) + "\n")
+	}
+
+	return buf.Bytes()
+}
+
+func propertiesEscape(s string, key bool) string {
+	var buf strings.Builder
+
+	for i, c := range s {
+		switch {
+		case c == '\\':
+			buf.WriteString(`\\`)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c == '\f':
+			buf.WriteString(`\f`)
+		case c == ' ' && (key || i == 0):
+			buf.WriteString(`\ `)
+		case key && (c == '=' || c == ':'):
+			buf.WriteString(`\` + string(c))
+		case (c == '#' || c == '!') && (key || i == 0):
+			buf.WriteString(`\` + string(c))
+		case c < 0x20 || c > 0x7e:
+			// properties files are read as ISO-8859-1
+			if r1, r2 := utf16.EncodeRune(c); r1 != unicode.ReplacementChar {
+				fmt.Fprintf(&buf, `\u%04x\u%04x`, r1, r2)
+			} else {
+				fmt.Fprintf(&buf, `\u%04x`, c)
+			}
+		default:
+			buf.WriteRune(c)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"testing"
+)
+
+func TestEncodeDotEnv(t *testing.T) {
+	t.Run("values are shell-escaped", func(t *testing.T) {
+		result := string(EncodeDotEnv(map[string]string{
+			"password": "it's $secret",
+			"user":     "admin",
+		}))
+
+		expected := "password='it'\\''s $secret'\nuser='admin'\n"
+		if result != expected {
+			t.Errorf("expected %q, got %q", expected, result)
+		}
+	})
+
+	t.Run("keys are converted to variable names", func(t *testing.T) {
+		result := string(EncodeDotEnv(map[string]string{
+			"tls/cert": "a",
+			"tls-cert": "b",
+			"1st":      "c",
+		}))
+
+		expected := "_1st='c'\ntls_cert='b'\n"
+		if result != expected {
+			t.Errorf("expected %q, got %q", expected, result)
+		}
+	})
+}
+
+func TestEncodeYaml(t *testing.T) {
+	t.Run("keys and values are double-quoted", func(t *testing.T) {
+		result := string(EncodeYaml(map[string]string{
+			"cert": "line1\nline2",
+			"url":  "http://a/?b=1&c=<d>",
+		}))
+
+		expected := "\"cert\": \"line1\\nline2\"\n\"url\": \"http://a/?b=1&c=<d>\"\n"
+		if result != expected {
+			t.Errorf("expected %q, got %q", expected, result)
+		}
+	})
+
+	t.Run("non printable characters and line separators are escaped", func(t *testing.T) {
+		result := string(EncodeYaml(map[string]string{
+			"control": "\x00\x1b\x7f\u0085\u0086\u2028\ufeff é 😀",
+		}))
+
+		expected := "\"control\": \"\\x00\\x1b\\x7f\\x85\\x86\\u2028\\ufeff é 😀\"\n"
+		if result != expected {
+			t.Errorf("expected %q, got %q", expected, result)
+		}
+	})
+}
+
+func TestEncodeProperties(t *testing.T) {
+	t.Run("keys and values are escaped", func(t *testing.T) {
+		result := string(EncodeProperties(map[string]string{
+			"a key=": " value\\with\nnewline",
+			"b":      "#not a comment, é 😀",
+		}))
+
+		expected := "a\\ key\\==\\ value\\\\with\\nnewline\n" +
+			"b=\\#not a comment, \\u00e9 \\ud83d\\ude00\n"
+		if result != expected {
+			t.Errorf("expected %q, got %q", expected, result)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"strings"
+)
+
+// SplitPem returns each PEM block of s, re-encoded.
+func SplitPem(s string) ([]string, error) {
+	r := []string{}
+
+	rest := []byte(s)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		r = append(r, string(pem.EncodeToMemory(block)))
+	}
+
+	if len(r) == 0 {
+		return nil, errors.New("no PEM block found")
+	}
+
+	return r, nil
+}
+
+// DecodeBase64 decodes a standard base64 value, with or without padding,
+// ignoring the whitespaces (eg. line breaks).
+func DecodeBase64(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+
+	return base64.RawStdEncoding.DecodeString(s)
+}
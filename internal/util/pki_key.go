@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+)
+
+// NewPrivateKey generates a private key of the given type (rsa, ec or
+// ed25519) and size in bits (ignored for ed25519).
+func NewPrivateKey(keyType string, bits int) (crypto.Signer, error) {
+	switch keyType {
+	case "rsa":
+		return rsa.GenerateKey(rand.Reader, bits)
+
+	case "ec":
+		var curve elliptic.Curve
+
+		switch bits {
+		case 224:
+			curve = elliptic.P224()
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC key size %d", bits)
+		}
+
+		return ecdsa.GenerateKey(curve, rand.Reader)
+
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s", keyType)
+}
+
+// EncodePrivateKeyPem encodes the private key as a PKCS#8 PEM block, or as a
+// PKCS#1 (RSA) or SEC1 (EC) one unless pkcs8 is set. Ed25519 keys are always
+// encoded as PKCS#8.
+func EncodePrivateKeyPem(key crypto.Signer, pkcs8 bool) (string, error) {
+	var block *pem.Block
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if !pkcs8 {
+			block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+		}
+
+	case *ecdsa.PrivateKey:
+		if !pkcs8 {
+			der, err := x509.MarshalECPrivateKey(k)
+			if err != nil {
+				return "", err
+			}
+
+			block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+		}
+	}
+
+	if block == nil {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// NewCertificateRequestPem creates a PEM-encoded certificate signing request
+// of the key, with the given subject common name.
+func NewCertificateRequestPem(key crypto.Signer, commonName string) (string, error) {
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestNewPrivateKey(t *testing.T) {
+	t.Run("keys are encoded in the requested format", func(t *testing.T) {
+		for _, tc := range []struct {
+			keyType   string
+			bits      int
+			pkcs8     bool
+			blockType string
+		}{
+			{"rsa", 2048, false, "RSA PRIVATE KEY"},
+			{"rsa", 2048, true, "PRIVATE KEY"},
+			{"ec", 384, false, "EC PRIVATE KEY"},
+			{"ec", 256, true, "PRIVATE KEY"},
+			{"ed25519", 0, false, "PRIVATE KEY"},
+		} {
+			key, err := NewPrivateKey(tc.keyType, tc.bits)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			keyPem, err := EncodePrivateKeyPem(key, tc.pkcs8)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			block, _ := pem.Decode([]byte(keyPem))
+			if block == nil || block.Type != tc.blockType {
+				t.Errorf("%s/%d: expected a %s block, got %q", tc.keyType, tc.bits, tc.blockType, keyPem)
+			}
+		}
+	})
+
+	t.Run("unsupported key type or size returns error", func(t *testing.T) {
+		if _, err := NewPrivateKey("dsa", 2048); err == nil {
+			t.Error("expected error for unsupported key type")
+		}
+
+		if _, err := NewPrivateKey("ec", 2048); err == nil {
+			t.Error("expected error for unsupported EC key size")
+		}
+	})
+}
+
+func TestNewCertificateRequestPem(t *testing.T) {
+	t.Run("request is signed by the key", func(t *testing.T) {
+		key, err := NewPrivateKey("ec", 256)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		csrPem, err := NewCertificateRequestPem(key, "web.example.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		block, _ := pem.Decode([]byte(csrPem))
+		if block == nil || block.Type != "CERTIFICATE REQUEST" {
+			t.Fatalf("expected a certificate request block, got %q", csrPem)
+		}
+
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := csr.CheckSignature(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if csr.Subject.CommonName != "web.example.com" {
+			t.Errorf("expected common name web.example.com, got %q", csr.Subject.CommonName)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"strings"
+)
+
+// SplitList splits a comma-separated list, trimming spaces and dropping empty items.
+func SplitList(s string) []string {
+	r := []string{}
+
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			r = append(r, v)
+		}
+	}
+
+	return r
+}
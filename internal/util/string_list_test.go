@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	t.Run("empty string returns empty list", func(t *testing.T) {
+		result := SplitList("")
+
+		if len(result) != 0 {
+			t.Errorf("expected empty list, got %v", result)
+		}
+	})
+
+	t.Run("items are trimmed and empty items dropped", func(t *testing.T) {
+		result := SplitList(" a, b ,,c ,")
+
+		if !slices.Equal(result, []string{"a", "b", "c"}) {
+			t.Errorf("expected [a b c], got %v", result)
+		}
+	})
+}
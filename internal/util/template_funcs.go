@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs returns the helpers available to the secret templates.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"b64enc":   templateB64Enc,
+		"b64dec":   templateB64Dec,
+		"toJson":   templateToJson,
+		"fromJson": templateFromJson,
+		"indent":   templateIndent,
+		"default":  templateDefault,
+	}
+}
+
+func templateB64Enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func templateB64Dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func templateToJson(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func templateFromJson(s string) (interface{}, error) {
+	var r interface{}
+	if err := json.Unmarshal([]byte(s), &r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// templateIndent prefixes every line of s with n spaces.
+func templateIndent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// templateDefault returns v, or d when v is empty (eg. {{ index . "port" | default "5432" }}).
+func templateDefault(d interface{}, v interface{}) interface{} {
+	if v == nil {
+		return d
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.IsZero() || ((rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.Len() == 0) {
+		return d
+	}
+
+	return v
+}
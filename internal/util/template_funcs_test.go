@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	render := func(t *testing.T, text string, data interface{}) string {
+		t.Helper()
+
+		tpl, err := template.New("test").Funcs(TemplateFuncs()).Parse(text)
+		if err != nil {
+			t.Fatalf("unexpected parse error: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, data); err != nil {
+			t.Fatalf("unexpected execute error: %v", err)
+		}
+
+		return buf.String()
+	}
+
+	data := map[string]string{
+		"user":  "admin",
+		"b64":   "aHVudGVyMg==",
+		"json":  `{"host":"db","port":5432}`,
+		"empty": "",
+		"multi": "a\nb",
+	}
+
+	t.Run("base64 helpers encode and decode", func(t *testing.T) {
+		if result := render(t, `{{ .user | b64enc }} {{ .b64 | b64dec }}`, data); result != "YWRtaW4= hunter2" {
+			t.Errorf("unexpected result %q", result)
+		}
+	})
+
+	t.Run("json helpers encode and decode", func(t *testing.T) {
+		if result := render(t, `{{ (.json | fromJson).host }} {{ .user | toJson }}`, data); result != `db "admin"` {
+			t.Errorf("unexpected result %q", result)
+		}
+	})
+
+	t.Run("indent prefixes every line", func(t *testing.T) {
+		if result := render(t, `{{ .multi | indent 2 }}`, data); result != "  a\n  b" {
+			t.Errorf("unexpected result %q", result)
+		}
+	})
+
+	t.Run("default replaces empty and missing values", func(t *testing.T) {
+		if result := render(t, `{{ .empty | default "x" }} {{ index . "missing" | default "y" }} {{ .user | default "z" }}`, data); result != "x y admin" {
+			t.Errorf("unexpected result %q", result)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type TlsServerFiles struct {
+	CertFile          string
+	KeyFile           string
+	ClientCAFile      *string
+	RequireClientCert bool
+}
+
+type tlsServerConfigReloader struct {
+	files TlsServerFiles
+
+	lock     *sync.Mutex
+	modTimes []time.Time
+	config   *tls.Config
+}
+
+// NewTlsServerConfig returns a TLS server configuration which reloads the
+// certificate, key and client CA files when they change on disk. When a
+// reload fails, the previously loaded files keep being used.
+func NewTlsServerConfig(files TlsServerFiles) (*tls.Config, error) {
+	if files.CertFile == "" || files.KeyFile == "" {
+		return nil, errors.New("both certificate and key files must be defined")
+	}
+
+	if files.RequireClientCert && files.ClientCAFile == nil {
+		return nil, errors.New("client certificate requirement needs a client CA file to be defined")
+	}
+
+	z := &tlsServerConfigReloader{
+		files: files,
+		lock:  &sync.Mutex{},
+	}
+
+	modTimes, err := z.statFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := z.load(modTimes); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: z.getConfigForClient,
+	}, nil
+}
+
+func (z *tlsServerConfigReloader) filePaths() []string {
+	paths := []string{z.files.CertFile, z.files.KeyFile}
+	if z.files.ClientCAFile != nil {
+		paths = append(paths, *z.files.ClientCAFile)
+	}
+
+	return paths
+}
+
+func (z *tlsServerConfigReloader) statFiles() ([]time.Time, error) {
+	paths := z.filePaths()
+	modTimes := make([]time.Time, 0, len(paths))
+
+	for _, path := range paths {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		modTimes = append(modTimes, fileInfo.ModTime())
+	}
+
+	return modTimes, nil
+}
+
+func (z *tlsServerConfigReloader) load(modTimes []time.Time) error {
+	certificate, err := tls.LoadX509KeyPair(z.files.CertFile, z.files.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate and key: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+		NextProtos:   []string{"http/1.1"},
+	}
+
+	if z.files.ClientCAFile != nil {
+		caContent, err := os.ReadFile(*z.files.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(caContent) {
+			return errors.New("no certificate found in client CA")
+		}
+
+		if z.files.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		} else {
+			config.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	z.modTimes = modTimes
+	z.config = config
+
+	return nil
+}
+
+func (z *tlsServerConfigReloader) changed(modTimes []time.Time) bool {
+	for i, modTime := range modTimes {
+		if !modTime.Equal(z.modTimes[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (z *tlsServerConfigReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	modTimes, err := z.statFiles()
+	if err != nil {
+		Errorf("Unable to check TLS files, keeping the loaded ones: %v\n", err)
+	} else if z.changed(modTimes) {
+		if err := z.load(modTimes); err != nil {
+			Errorf("Unable to reload TLS files, keeping the loaded ones: %v\n", err)
+		} else {
+			Printf("TLS files reloaded\n")
+		}
+	}
+
+	return z.config, nil
+}
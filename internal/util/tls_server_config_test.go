@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, certFile string, keyFile string, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func commonNameForClient(t *testing.T, config *tls.Config) string {
+	t.Helper()
+
+	clientConfig, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(clientConfig.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return certificate.Subject.CommonName
+}
+
+func TestNewTlsServerConfig(t *testing.T) {
+	t.Run("missing key file returns error", func(t *testing.T) {
+		if _, err := NewTlsServerConfig(TlsServerFiles{CertFile: "cert.pem"}); err == nil {
+			t.Error("expected error for missing key file")
+		}
+	})
+
+	t.Run("client certificate requirement without client CA returns error", func(t *testing.T) {
+		if _, err := NewTlsServerConfig(TlsServerFiles{CertFile: "cert.pem", KeyFile: "key.pem", RequireClientCert: true}); err == nil {
+			t.Error("expected error for client certificate requirement without client CA")
+		}
+	})
+
+	t.Run("client CA enables client certificate verification", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile := path.Join(dir, "cert.pem")
+		keyFile := path.Join(dir, "key.pem")
+		writeTestCertificate(t, certFile, keyFile, "server")
+
+		config, err := NewTlsServerConfig(TlsServerFiles{CertFile: certFile, KeyFile: keyFile, ClientCAFile: &certFile, RequireClientCert: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		clientConfig, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if clientConfig.ClientAuth != tls.RequireAndVerifyClientCert || clientConfig.ClientCAs == nil {
+			t.Errorf("expected client certificates to be required and verified, got %v", clientConfig.ClientAuth)
+		}
+	})
+
+	t.Run("certificate is reloaded when files change", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile := path.Join(dir, "cert.pem")
+		keyFile := path.Join(dir, "key.pem")
+		writeTestCertificate(t, certFile, keyFile, "first")
+
+		config, err := NewTlsServerConfig(TlsServerFiles{CertFile: certFile, KeyFile: keyFile})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if commonName := commonNameForClient(t, config); commonName != "first" {
+			t.Errorf("expected common name %q, got %q", "first", commonName)
+		}
+
+		writeTestCertificate(t, certFile, keyFile, "second")
+		modTime := time.Now().Add(time.Minute)
+		os.Chtimes(certFile, modTime, modTime)
+
+		if commonName := commonNameForClient(t, config); commonName != "second" {
+			t.Errorf("expected common name %q, got %q", "second", commonName)
+		}
+	})
+
+	t.Run("invalid files keep the previous certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		certFile := path.Join(dir, "cert.pem")
+		keyFile := path.Join(dir, "key.pem")
+		writeTestCertificate(t, certFile, keyFile, "first")
+
+		config, err := NewTlsServerConfig(TlsServerFiles{CertFile: certFile, KeyFile: keyFile})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		modTime := time.Now().Add(time.Minute)
+		os.Chtimes(certFile, modTime, modTime)
+
+		if commonName := commonNameForClient(t, config); commonName != "first" {
+			t.Errorf("expected common name %q, got %q", "first", commonName)
+		}
+	})
+}
@@ -1,14 +1,67 @@
-// SPDX-FileCopyrightText: © 2024 Anthony Champagne <dev@anthonychampagne.fr>
+// SPDX-FileCopyrightText: © 2024 - 2026 Anthony Champagne <dev@anthonychampagne.fr>
 //
 // SPDX-License-Identifier: AGPL-3.0-only
 
 package util
 
-// MapStringStringFromMapStringInterface converts a map[string]interface{} to map[string]string.
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// StringFromInterface renders scalar values as text, and lists and objects as JSON.
+func StringFromInterface(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	case bool:
+		return strconv.FormatBool(s)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(s), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(s)
+	}
+
+	content, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(content)
+}
+
+// MapStringStringFromMapStringInterface converts a map[string]interface{} to map[string]string,
+// rendering the values with StringFromInterface.
 func MapStringStringFromMapStringInterface(m map[string]interface{}) map[string]string {
 	r := map[string]string{}
 	for k, v := range m {
-		r[k] = v.(string)
+		r[k] = StringFromInterface(v)
 	}
 	return r
 }
+
+// FlattenMapStringInterface converts a map[string]interface{} to map[string]string,
+// nested objects keys being joined to their parent key with sep, and the other
+// values being rendered with StringFromInterface.
+func FlattenMapStringInterface(m map[string]interface{}, sep string) map[string]string {
+	r := map[string]string{}
+	flattenMapStringInterface(r, "", m, sep)
+	return r
+}
+
+func flattenMapStringInterface(r map[string]string, prefix string, m map[string]interface{}, sep string) {
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenMapStringInterface(r, prefix+k+sep, nested, sep)
+		} else {
+			r[prefix+k] = StringFromInterface(v)
+		}
+	}
+}
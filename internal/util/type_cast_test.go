@@ -5,6 +5,7 @@
 package util
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -38,3 +39,67 @@ func TestMapStringStringFromMapStringInterface(t *testing.T) {
 		}
 	})
 }
+
+func TestStringFromInterface(t *testing.T) {
+	t.Run("scalars are rendered as text", func(t *testing.T) {
+		for _, tc := range []struct {
+			value    interface{}
+			expected string
+		}{
+			{"text", "text"},
+			{json.Number("42"), "42"},
+			{float64(3.5), "3.5"},
+			{float64(1e21), "1000000000000000000000"},
+			{true, "true"},
+			{nil, ""},
+		} {
+			if result := StringFromInterface(tc.value); result != tc.expected {
+				t.Errorf("expected %q for %#v, got %q", tc.expected, tc.value, result)
+			}
+		}
+	})
+
+	t.Run("lists and objects are rendered as JSON", func(t *testing.T) {
+		if result := StringFromInterface([]interface{}{"a", float64(1)}); result != `["a",1]` {
+			t.Errorf("expected %q, got %q", `["a",1]`, result)
+		}
+
+		if result := StringFromInterface(map[string]interface{}{"b": true}); result != `{"b":true}` {
+			t.Errorf("expected %q, got %q", `{"b":true}`, result)
+		}
+	})
+}
+
+func TestFlattenMapStringInterface(t *testing.T) {
+	t.Run("nested objects keys are joined to their parent key", func(t *testing.T) {
+		result := FlattenMapStringInterface(map[string]interface{}{
+			"tls": map[string]interface{}{
+				"cert": "CERT",
+				"key":  "KEY",
+			},
+			"db": map[string]interface{}{
+				"port":  float64(5432),
+				"hosts": []interface{}{"a", "b"},
+			},
+			"empty": map[string]interface{}{},
+		}, "/")
+
+		expected := map[string]string{
+			"tls/cert": "CERT",
+			"tls/key":  "KEY",
+			"db/port":  "5432",
+			"db/hosts": `["a","b"]`,
+			"empty":    "{}",
+		}
+
+		if len(result) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, result)
+		}
+
+		for k, v := range expected {
+			if result[k] != v {
+				t.Errorf("expected %s=%q, got %q", k, v, result[k])
+			}
+		}
+	})
+}
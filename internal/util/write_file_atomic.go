@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+// WriteFileAtomic writes data to a temporary file which is synced to disk and
+// then renamed to the named file, so that the named file is either left
+// untouched or fully written.
+func WriteFileAtomic(name string, data []byte, perm os.FileMode) error {
+	dir := path.Dir(name)
+
+	f, err := os.CreateTemp(dir, "."+path.Base(name)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temporary file: %w", err)
+	}
+
+	tmpName := f.Name()
+	defer os.Remove(tmpName)
+
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return fmt.Errorf("update temporary file access modes: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write temporary file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync temporary file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmpName, name); err != nil {
+		return fmt.Errorf("rename temporary file: %w", err)
+	}
+
+	// persist the rename itself
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+
+	d.Sync()
+
+	return nil
+}
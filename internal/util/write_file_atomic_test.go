@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: © 2026 Anthony Champagne <dev@anthonychampagne.fr>
+//
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"os"
+	"path"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Run("writes the file with the given access modes", func(t *testing.T) {
+		name := path.Join(t.TempDir(), "state.json")
+
+		if err := WriteFileAtomic(name, []byte("content"), 0o600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(content) != "content" {
+			t.Errorf("expected %q, got %q", "content", content)
+		}
+
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("expected mode 0600, got %04o", info.Mode().Perm())
+		}
+	})
+
+	t.Run("replaces an existing file without leaving temporary files", func(t *testing.T) {
+		dir := t.TempDir()
+		name := path.Join(dir, "state.json")
+
+		if err := os.WriteFile(name, []byte("old"), 0o600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := WriteFileAtomic(name, []byte("new"), 0o600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		content, _ := os.ReadFile(name)
+		if string(content) != "new" {
+			t.Errorf("expected %q, got %q", "new", content)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(entries) != 1 {
+			t.Errorf("expected a single file in directory, got %d", len(entries))
+		}
+	})
+
+	t.Run("missing directory returns error", func(t *testing.T) {
+		name := path.Join(t.TempDir(), "missing", "state.json")
+
+		if err := WriteFileAtomic(name, []byte("content"), 0o600); err == nil {
+			t.Error("expected error for missing directory")
+		}
+	})
+}
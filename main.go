@@ -6,6 +6,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"os/signal"
@@ -86,6 +87,43 @@ func main() {
 				Usage:       "Skip verification of Vault server TLS certificate",
 				Destination: &defaultOptDocker.Secret.Vault.ClientHttp.Tls.Insecure,
 			},
+			&cli.StringFlag{
+				Category: "Vault Client Options",
+				Name:     "vault-ca-cert-file",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix+"VAULT_CA_CERT_FILE", "VAULT_CACERT"),
+				Usage:    "Vault server CA certificate file",
+			},
+			&cli.StringFlag{
+				Category: "Vault Client Options",
+				Name:     "vault-client-cert-file",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix+"VAULT_CLIENT_CERT_FILE", "VAULT_CLIENT_CERT"),
+				Usage:    "Vault client certificate file",
+			},
+			&cli.StringFlag{
+				Category: "Vault Client Options",
+				Name:     "vault-client-key-file",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix+"VAULT_CLIENT_KEY_FILE", "VAULT_CLIENT_KEY"),
+				Usage:    "Vault client certificate key file",
+			},
+			&cli.StringFlag{
+				Category: "Vault Client Options",
+				Name:     "vault-tls-server-name",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix+"VAULT_TLS_SERVER_NAME", "VAULT_TLS_SERVER_NAME"),
+				Usage:    "Vault server name used for TLS verification (SNI)",
+			},
+			&cli.StringFlag{
+				Category: "Vault Client Options",
+				Name:     "namespace",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix+"NAMESPACE", "VAULT_NAMESPACE"),
+				Usage:    "Default Vault namespace (Vault Enterprise or OpenBao)",
+			},
+			&cli.StringFlag{
+				Category:    "Vault Client Options",
+				Name:        "auth-namespace",
+				Sources:     cli.EnvVars(constants.EnvVarsPrefix + "AUTH_NAMESPACE"),
+				DefaultText: "<namespace>",
+				Usage:       "Default Auth method namespace",
+			},
 
 			&cli.StringFlag{
 				Category:    "Vault Client Options",
@@ -120,6 +158,13 @@ func main() {
 				DefaultText: defaultOptDocker.Secret.Vault.VaultEngine.EffectiveMountPath(),
 				Usage:       "Default Vault Secrets engine mount path",
 			},
+			&cli.StringFlag{
+				Category:    "Vault Secrets",
+				Name:        "engine-namespace",
+				Sources:     cli.EnvVars(constants.EnvVarsPrefix + "ENGINE_NAMESPACE"),
+				DefaultText: "<namespace>",
+				Usage:       "Default Vault Secrets engine namespace",
+			},
 			&cli.IntFlag{
 				Category:    "Vault Secrets",
 				Name:        "kv-engine-version",
@@ -144,6 +189,31 @@ func main() {
 				DefaultText: "<undefined>",
 				Usage:       "Docker Plugin TCP bind port",
 			},
+			&cli.StringFlag{
+				Category: "Docker Plugin",
+				Name:     "plugin-tcp-tls-cert-file",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "PLUGIN_TCP_TLS_CERT_FILE"),
+				Usage:    "Docker Plugin TCP TLS server certificate file (PEM, reloaded on change)",
+			},
+			&cli.StringFlag{
+				Category: "Docker Plugin",
+				Name:     "plugin-tcp-tls-key-file",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "PLUGIN_TCP_TLS_KEY_FILE"),
+				Usage:    "Docker Plugin TCP TLS server private key file (PEM, reloaded on change)",
+			},
+			&cli.StringFlag{
+				Category: "Docker Plugin",
+				Name:     "plugin-tcp-tls-client-ca-file",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "PLUGIN_TCP_TLS_CLIENT_CA_FILE"),
+				Usage:    "Docker Plugin TCP TLS client CA certificates file (PEM, reloaded on change)",
+			},
+			&cli.BoolFlag{
+				Category: "Docker Plugin",
+				Name:     "plugin-tcp-tls-require-client-cert",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "PLUGIN_TCP_TLS_REQUIRE_CLIENT_CERT"),
+				Value:    false,
+				Usage:    "Require Docker Plugin TCP TLS client certificates",
+			},
 			&cli.StringFlag{
 				Category: "Docker Plugin",
 				Name:     "plugin-socket-path",
@@ -194,6 +264,38 @@ func main() {
 				Value:    path.Join("/var/local", constants.AppName, "state.json"),
 				Usage:    "Volume Driver state file",
 			},
+			&cli.StringFlag{
+				Category: "Docker Volume Driver",
+				Name:     "volume-driver-state-key-file",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "VOLUME_DRIVER_STATE_KEY_FILE"),
+				Usage:    "Volume Driver state file encryption key file (32 bytes key, raw, hexadecimal or base64 encoded)",
+			},
+			&cli.StringFlag{
+				Category: "Docker Volume Driver",
+				Name:     "volume-driver-state-passphrase",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "VOLUME_DRIVER_STATE_PASSPHRASE"),
+				Usage:    "Volume Driver state file encryption passphrase",
+			},
+			&cli.StringFlag{
+				Category: "Docker Volume Driver",
+				Name:     "volume-driver-state-transit-key",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "VOLUME_DRIVER_STATE_TRANSIT_KEY"),
+				Usage:    "Volume Driver state file encryption Vault Transit key name (uses the default Vault client and auth options)",
+			},
+			&cli.StringFlag{
+				Category: "Docker Volume Driver",
+				Name:     "volume-driver-state-transit-mount",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "VOLUME_DRIVER_STATE_TRANSIT_MOUNT"),
+				Value:    "transit",
+				Usage:    "Volume Driver state file encryption Vault Transit engine mount path",
+			},
+			&cli.BoolFlag{
+				Category: "Docker Volume Driver",
+				Name:     "volume-driver-state-migrate",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "VOLUME_DRIVER_STATE_MIGRATE"),
+				Value:    false,
+				Usage:    "Encrypt an existing plaintext Volume Driver state file",
+			},
 			&cli.StringFlag{
 				Category: "Docker Volume Driver",
 				Name:     "volume-driver-mount-dir",
@@ -215,6 +317,13 @@ func main() {
 				Value:    currentGroup.Name,
 				Usage:    "Volume Driver FS mount group name or ID",
 			},
+			&cli.StringFlag{
+				Category: "Docker Volume Driver",
+				Name:     "volume-driver-template-dir",
+				Sources:  cli.EnvVars(constants.EnvVarsPrefix + "VOLUME_DRIVER_TEMPLATE_DIR"),
+				Value:    path.Join("/var/local", constants.AppName, "templates"),
+				Usage:    "Volume Driver templates files directory (template-file-<file> volume options)",
+			},
 			&cli.BoolFlag{
 				Category: "Docker Secret Provider",
 				Name:     "disable-secret-provider",
@@ -224,6 +333,41 @@ func main() {
 			},
 		},
 		Action: func(ctx context.Context, c *cli.Command) error {
+			if c.IsSet("vault-ca-cert-file") {
+				v := c.String("vault-ca-cert-file")
+				defaultOptDocker.Secret.Vault.ClientHttp.Tls.CACertFile = &v
+			}
+
+			if c.IsSet("vault-client-cert-file") {
+				v := c.String("vault-client-cert-file")
+				defaultOptDocker.Secret.Vault.ClientHttp.Tls.CertFile = &v
+			}
+
+			if c.IsSet("vault-client-key-file") {
+				v := c.String("vault-client-key-file")
+				defaultOptDocker.Secret.Vault.ClientHttp.Tls.KeyFile = &v
+			}
+
+			if c.IsSet("vault-tls-server-name") {
+				v := c.String("vault-tls-server-name")
+				defaultOptDocker.Secret.Vault.ClientHttp.Tls.ServerName = &v
+			}
+
+			// empty values are ignored so that the plugin settable environment
+			// variables don't override the namespace option
+			if v := c.String("namespace"); v != "" {
+				defaultOptDocker.Secret.Vault.VaultAuth.Namespace = &v
+				defaultOptDocker.Secret.Vault.VaultEngine.Namespace = &v
+			}
+
+			if v := c.String("auth-namespace"); v != "" {
+				defaultOptDocker.Secret.Vault.VaultAuth.Namespace = &v
+			}
+
+			if v := c.String("engine-namespace"); v != "" {
+				defaultOptDocker.Secret.Vault.VaultEngine.Namespace = &v
+			}
+
 			if c.IsSet("auth-mount") {
 				v := c.String("auth-mount")
 				defaultOptDocker.Secret.Vault.VaultAuth.MountPath = &v
@@ -282,25 +426,62 @@ func start(ctx context.Context, c *cli.Command, defaultOptDocker options.OptDock
 		tcpBindPort = &tbp16
 	}
 
+	var tcpTlsConfig *tls.Config
+	// any TLS flag enables TLS, so that a partial TLS configuration fails
+	// instead of silently falling back to plaintext
+	if c.IsSet("plugin-tcp-tls-cert-file") || c.IsSet("plugin-tcp-tls-key-file") || c.IsSet("plugin-tcp-tls-client-ca-file") || c.IsSet("plugin-tcp-tls-require-client-cert") {
+		tlsServerFiles := util.TlsServerFiles{
+			CertFile:          c.String("plugin-tcp-tls-cert-file"),
+			KeyFile:           c.String("plugin-tcp-tls-key-file"),
+			RequireClientCert: c.Bool("plugin-tcp-tls-require-client-cert"),
+		}
+		if v := c.String("plugin-tcp-tls-client-ca-file"); v != "" {
+			tlsServerFiles.ClientCAFile = &v
+		}
+
+		tcpTlsConfig, err = util.NewTlsServerConfig(tlsServerFiles)
+		if err != nil {
+			return fmt.Errorf("create plugin tcp tls config: %w", err)
+		}
+	} else if tcpBindPort != nil {
+		util.Noticef("Docker Plugin TCP socket is not using TLS, secrets will be transmitted in plaintext\n")
+	}
+
 	unixSocketPath := c.String("plugin-socket-path")
 
+	stateEncryption := docker.VolumeDriverStateEncryptionConfig{
+		TransitMountPath: c.String("volume-driver-state-transit-mount"),
+		MigratePlaintext: c.Bool("volume-driver-state-migrate"),
+	}
+	if v := c.String("volume-driver-state-key-file"); v != "" {
+		stateEncryption.KeyFilePath = &v
+	}
+	if v := c.String("volume-driver-state-passphrase"); v != "" {
+		stateEncryption.Passphrase = &v
+	}
+	if v := c.String("volume-driver-state-transit-key"); v != "" {
+		stateEncryption.TransitKeyName = &v
+	}
+
 	dockerPlugin, err := docker.NewPlugin(docker.PluginConfig{
 		TcpBindAddr:    &tcpBindAddr,
 		TcpBindPort:    tcpBindPort,
-		TcpTlsConfig:   nil, // TODO
+		TcpTlsConfig:   tcpTlsConfig,
 		UnixSocketPath: &unixSocketPath,
 		UnixSocketUId:  unixSocketUId,
 		UnixSocketGId:  unixSocketGId,
 		UnixSocketMode: uint32(c.Uint("plugin-socket-mode")),
 
-		VolumeDriverDisabled:      c.Bool("disable-volume-driver"),
-		VolumeDriverGlobalScope:   c.Bool("volume-driver-global-scope"),
-		VolumeDriverStateFilePath: c.String("volume-driver-state-file"),
+		VolumeDriverDisabled:        c.Bool("disable-volume-driver"),
+		VolumeDriverGlobalScope:     c.Bool("volume-driver-global-scope"),
+		VolumeDriverStateFilePath:   c.String("volume-driver-state-file"),
+		VolumeDriverStateEncryption: stateEncryption,
 		VolumeDriverFsConfig: docker.FsConfig{
 			MountFuseName: constants.AppName,
 			MountDir:      c.String("volume-driver-mount-dir"),
 			MountDirUId:   mountDirUId,
 			MountDirGId:   mountDirGId,
+			TemplateDir:   c.String("volume-driver-template-dir"),
 		},
 
 		SecretProviderDisabled: c.Bool("disable-secret-provider"),